@@ -0,0 +1,123 @@
+// Package acks keeps track of the answers from the owners of the teams, i.e. who confirmed that the members of a team
+// are correct, or reported that the team needs changes, and when.
+package acks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/nais/slack-teams-notification/internal/fileutils"
+)
+
+type Status string
+
+const (
+	// StatusConfirmed means that the owner confirmed that the members of the team are correct.
+	StatusConfirmed Status = "confirmed"
+
+	// StatusNeedsChanges means that the owner reported that the members of the team need to be changed.
+	StatusNeedsChanges Status = "needs_changes"
+)
+
+// Acknowledgement is an answer from a Slack user to the notification about a team.
+type Acknowledgement struct {
+	TeamSlug string    `json:"teamSlug"`
+	UserID   string    `json:"userId"`
+	UserName string    `json:"userName"`
+	Status   Status    `json:"status"`
+	At       time.Time `json:"at"`
+}
+
+// Store persists acknowledgements.
+type Store interface {
+	// Record stores the acknowledgement.
+	Record(ctx context.Context, ack Acknowledgement) error
+
+	// List returns all acknowledgements of the team, oldest first.
+	List(ctx context.Context, teamSlug string) ([]Acknowledgement, error)
+}
+
+type fileContent struct {
+	Acknowledgements []Acknowledgement `json:"acknowledgements"`
+}
+
+// FileStore is a Store that keeps the acknowledgements in a JSON file. The file is created on the first write. Writes
+// take a lock on the file, with the suffix ".lock", so the file can be shared between processes, e.g. the server in the
+// "serve" mode and the "check-acks" job, as long as they mount the same volume.
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (s *FileStore) Record(_ context.Context, ack Acknowledgement) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := fileutils.Lock(s.path + ".lock")
+	if err != nil {
+		return fmt.Errorf("lock acknowledgements: %w", err)
+	}
+	defer unlock()
+
+	content, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	content.Acknowledgements = append(content.Acknowledgements, ack)
+	data, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode acknowledgements: %w", err)
+	}
+
+	if err := fileutils.WriteAtomic(s.path, data); err != nil {
+		return fmt.Errorf("write acknowledgements: %w", err)
+	}
+
+	return nil
+}
+
+func (s *FileStore) List(_ context.Context, teamSlug string) ([]Acknowledgement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	content, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+
+	acks := make([]Acknowledgement, 0)
+	for _, ack := range content.Acknowledgements {
+		if ack.TeamSlug == teamSlug {
+			acks = append(acks, ack)
+		}
+	}
+
+	return acks, nil
+}
+
+func (s *FileStore) read() (*fileContent, error) {
+	content := &fileContent{}
+	data, err := os.ReadFile(filepath.Clean(s.path))
+	if errors.Is(err, os.ErrNotExist) {
+		return content, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("read acknowledgements: %w", err)
+	}
+
+	if err := json.Unmarshal(data, content); err != nil {
+		return nil, fmt.Errorf("decode acknowledgements: %w", err)
+	}
+
+	return content, nil
+}
@@ -0,0 +1,81 @@
+package acks_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nais/slack-teams-notification/internal/acks"
+)
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "acks.json")
+
+	t.Run("empty store", func(t *testing.T) {
+		store := acks.NewFileStore(path)
+		list, err := store.List(ctx, "team1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(list) != 0 {
+			t.Fatalf("expected no acknowledgements, got: %v", list)
+		}
+	})
+
+	t.Run("record and list", func(t *testing.T) {
+		store := acks.NewFileStore(path)
+		at := time.Date(2026, 10, 11, 10, 0, 0, 0, time.UTC)
+		for _, ack := range []acks.Acknowledgement{
+			{TeamSlug: "team1", UserID: "U1", Status: acks.StatusConfirmed, At: at},
+			{TeamSlug: "team2", UserID: "U2", Status: acks.StatusNeedsChanges, At: at},
+			{TeamSlug: "team1", UserID: "U3", Status: acks.StatusNeedsChanges, At: at.Add(time.Hour)},
+		} {
+			if err := store.Record(ctx, ack); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		// A new store reads what the first one wrote
+		list, err := acks.NewFileStore(path).List(ctx, "team1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(list) != 2 {
+			t.Fatalf("expected 2 acknowledgements, got: %v", list)
+		}
+
+		if list[0].UserID != "U1" || list[1].UserID != "U3" || !list[1].At.Equal(at.Add(time.Hour)) {
+			t.Errorf("unexpected acknowledgements: %v", list)
+		}
+	})
+}
+
+func TestFileStore_ConcurrentWriters(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "acks.json")
+
+	// Each store stands in for a separate process, e.g. the server and the check-acks job, sharing the file
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Go(func() {
+			store := acks.NewFileStore(path)
+			for j := range 5 {
+				ack := acks.Acknowledgement{TeamSlug: "team1", UserID: fmt.Sprintf("U%d-%d", i, j), Status: acks.StatusConfirmed}
+				if err := store.Record(ctx, ack); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	if list, err := acks.NewFileStore(path).List(ctx, "team1"); err != nil || len(list) != 50 {
+		t.Fatalf("expected every acknowledgement to be kept, got %d: %v", len(list), err)
+	}
+}
@@ -3,7 +3,11 @@ package slackteamsnotification
 import (
 	"context"
 	"fmt"
+	"slices"
+	"time"
 
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/nais/slack-teams-notification/internal/teamfilter"
 	"github.com/sethvargo/go-envconfig"
 )
 
@@ -16,13 +20,39 @@ type LogConfig struct {
 }
 
 type SlackConfig struct {
-	// Credential is the credential used with the Slack API.
-	Credential string `env:"SLACK_API_TOKEN,required"`
+	// Credential is the credential used with the Slack API. Required unless the run only exports a snapshot.
+	Credential string `env:"SLACK_API_TOKEN"`
+
+	// Digest makes the notifier send a single message to each owner, covering all the teams they own, instead of one
+	// message per team.
+	Digest bool `env:"SLACK_DIGEST,default=false"`
+
+	// UserCachePath is the path to a file where the Slack user directory is cached between runs. The directory is
+	// fetched from Slack on every run if empty.
+	UserCachePath string `env:"SLACK_USER_CACHE_PATH"`
+
+	// UserCacheTTL is how long the cached Slack user directory can be used before it is fetched again.
+	UserCacheTTL time.Duration `env:"SLACK_USER_CACHE_TTL,default=24h"`
+
+	// AdminChannel is the Slack channel that gets a summary of each run, and is notified about teams whose owners have
+	// not answered the reminder, even after the follow-ups.
+	AdminChannel string `env:"SLACK_ADMIN_CHANNEL"`
+
+	// RedirectRecipient is a Slack user ID or channel that receives every message instead of the owners and channels
+	// of the teams. Each message says who would have received it. Used to check the messages in a test workspace.
+	RedirectRecipient string `env:"SLACK_REDIRECT_RECIPIENT"`
+
+	// DryRun makes the notifier go through the whole run, but write the messages to DryRunOutput instead of posting
+	// them to Slack. Can also be enabled with the --dry-run flag.
+	DryRun bool `env:"DRY_RUN,default=false"`
+
+	// DryRunOutput is the file the messages are written to in a dry run. The messages are written to stdout if empty.
+	DryRunOutput string `env:"DRY_RUN_OUTPUT"`
 }
 
 type NaisAPIConfig struct {
 	// Credential is the credential used with the Nais API.
-	Credential string `env:"NAIS_API_TOKEN,required"`
+	Credential string `env:"NAIS_API_TOKEN"`
 
 	// Endpoint is the URL to the GraphQL API.
 	Endpoint string `env:"NAIS_API_ENDPOINT,default=https://console.nav.cloud.nais.io/graphql"`
@@ -31,22 +61,163 @@ type NaisAPIConfig struct {
 	// owners of the teams.
 	ConsoleURL string `env:"CONSOLE_URL,default=https://console.nav.cloud.nais.io/"`
 
+	// MaxRetries is the maximum number of times a failed request to the Nais API is retried.
+	MaxRetries int `env:"NAIS_API_MAX_RETRIES,default=3"`
+
+	// RetryInitialBackoff is the delay before the first retry. The delay is doubled for each subsequent retry.
+	RetryInitialBackoff time.Duration `env:"NAIS_API_RETRY_INITIAL_BACKOFF,default=1s"`
+
+	// RetryMaxBackoff is the upper limit of the delay between two retries. A request fails without being retried if the
+	// Nais API asks for a longer delay through Retry-After.
+	RetryMaxBackoff time.Duration `env:"NAIS_API_RETRY_MAX_BACKOFF,default=30s"`
+
+	// PartialDataPolicy decides what to do when the Nais API responds with both data and errors. "fail" aborts the
+	// run, while "warn" logs the errors and continues with the data that was returned.
+	PartialDataPolicy string `env:"NAIS_API_PARTIAL_DATA,default=fail"`
+
+	// partialDataPolicy is PartialDataPolicy parsed by validateConfig.
+	partialDataPolicy naisapi.PartialDataPolicy
+
 	// TeamsFilter is a list that can be supplied to only send a message to the teams included in the filter.
+	//
+	// Deprecated: use TeamSourceConfig.Include instead. The slugs in the filter are added to the include patterns.
 	TeamsFilter []string `env:"TEAMS_FILTER"`
 }
 
+type AcknowledgementConfig struct {
+	// Buttons adds buttons to the notifications where the owners can confirm that the members of the team are correct,
+	// or report that the team needs changes. The interactivity request URL of the Slack app must point to an instance
+	// running in the "serve" mode.
+	Buttons bool `env:"SLACK_ACK_BUTTONS,default=false"`
+
+	// SigningSecret is the signing secret of the Slack app, used to verify that interaction requests come from Slack.
+	SigningSecret string `env:"SLACK_SIGNING_SECRET"`
+
+	// StorePath is the path to the file where the answers from the owners are stored.
+	StorePath string `env:"ACK_STORE_PATH"`
+
+	// Reaction is the emoji that an owner can react with to confirm that the members of the team are correct. The
+	// reactions are checked in the "check-acks" mode.
+	Reaction string `env:"ACK_REACTION,default=white_check_mark"`
+
+	// ListenAddress is the address the HTTP server listens on in the "serve" mode.
+	ListenAddress string `env:"LISTEN_ADDRESS,default=:8080"`
+}
+
+type LedgerConfig struct {
+	// Path is the path to the file where every delivered notification is recorded. Nothing is recorded if empty.
+	Path string `env:"LEDGER_PATH"`
+
+	// Period is the run period the deliveries are recorded in, e.g. "2026-10". Owners that have already been notified
+	// about a team in the period are skipped, so a failed run can be retried without sending duplicates. Defaults to
+	// the current month.
+	Period string `env:"LEDGER_PERIOD"`
+}
+
+type FollowUpConfig struct {
+	// AfterDays is the number of days after the reminder the owners get a second reminder, if none of them has
+	// answered.
+	AfterDays int `env:"FOLLOW_UP_AFTER_DAYS,default=7"`
+
+	// EscalateAfterDays is the number of days after the second reminder the Slack channel of the team is notified,
+	// and the number of days after that the admin channel is notified.
+	EscalateAfterDays int `env:"FOLLOW_UP_ESCALATE_AFTER_DAYS,default=7"`
+}
+
+type ChannelConfig struct {
+	// PreferencesPath is the path to a YAML or JSON file with the channel each team wants its reminder delivered
+	// through: "slack", "email" or "webhook". Teams that are not in the file, or every team if empty, are notified in
+	// Slack.
+	PreferencesPath string `env:"CHANNEL_PREFERENCES_PATH"`
+
+	// WebhookURL is the URL the reminders of the "webhook" channel are posted to, unless the preference of the team
+	// has its own URL.
+	WebhookURL string `env:"WEBHOOK_URL"`
+
+	// SMTPHost is the mail server the reminders of the "email" channel are sent through. The email channel is
+	// disabled if empty.
+	SMTPHost string `env:"SMTP_HOST"`
+
+	// SMTPPort is the port of the mail server.
+	SMTPPort int `env:"SMTP_PORT,default=587"`
+
+	// SMTPUsername and SMTPPassword authenticate with the mail server. No authentication is done if SMTPUsername is
+	// empty.
+	SMTPUsername string `env:"SMTP_USERNAME"`
+	SMTPPassword string `env:"SMTP_PASSWORD"`
+
+	// SMTPFrom is the sender address of the emails.
+	SMTPFrom string `env:"SMTP_FROM"`
+}
+
+const (
+	modeNotify    = "notify"
+	modeServe     = "serve"
+	modeFollowUp  = "follow-up"
+	modeCheckAcks = "check-acks"
+)
+
+const (
+	teamSourceNaisAPI  = "nais-api"
+	teamSourceFile     = "file"
+	teamSourceSnapshot = "snapshot"
+)
+
+type TeamSourceConfig struct {
+	// Kind is where the teams to notify are read from: "nais-api", "file" (a static YAML or JSON file) or "snapshot"
+	// (a snapshot saved by an earlier run).
+	Kind string `env:"TEAM_SOURCE,default=nais-api"`
+
+	// Path is the path to the file used by the "file" and "snapshot" sources.
+	Path string `env:"TEAM_SOURCE_PATH"`
+
+	// Include is a list of patterns for the teams to notify. A pattern is either a regular expression enclosed in
+	// slashes, e.g. "/^team-[ab]$/", or a glob pattern, e.g. "team-a*". All teams are included if the list is empty.
+	Include []string `env:"TEAMS_INCLUDE"`
+
+	// Exclude is a list of patterns for teams that should never be notified, e.g. "nais-*". Exclude takes precedence
+	// over Include.
+	Exclude []string `env:"TEAMS_EXCLUDE"`
+
+	// SnapshotExportPath is where a snapshot of the fetched teams is written, which can later be replayed with the
+	// "snapshot" source. If the path is a directory, a file named after the time of the snapshot is created in it.
+	SnapshotExportPath string `env:"SNAPSHOT_EXPORT_PATH"`
+
+	// SnapshotExportOnly makes the run stop after the snapshot has been written, without notifying any teams.
+	SnapshotExportOnly bool `env:"SNAPSHOT_EXPORT_ONLY,default=false"`
+}
+
 type config struct {
-	Log     *LogConfig
-	Slack   *SlackConfig
-	NaisAPI *NaisAPIConfig
+	// Mode is what the program does: "notify" notifies the teams, "follow-up" follows up the teams whose owners have
+	// not answered, "check-acks" records the reactions from the owners as answers, and "serve" runs an HTTP server that
+	// handles the answers from the acknowledgement buttons. Can also be given as the first argument.
+	Mode string `env:"RUN_MODE,default=notify"`
+
+	// FailureThreshold is the share of the teams, between 0 and 1, that can fail to be notified before the run exits
+	// with a distinct exit code, so the Naisjob is marked as failed.
+	FailureThreshold float64 `env:"FAILURE_THRESHOLD,default=0.1"`
+
+	Log        *LogConfig
+	Slack      *SlackConfig
+	NaisAPI    *NaisAPIConfig
+	TeamSource *TeamSourceConfig
+	Ack        *AcknowledgementConfig
+	Ledger     *LedgerConfig
+	FollowUp   *FollowUpConfig
+	Channels   *ChannelConfig
 }
 
-func newConfig(ctx context.Context) (*config, error) {
+// newConfig loads the configuration from the environment, overridden by the command line arguments in args.
+func newConfig(ctx context.Context, args []string) (*config, error) {
 	cfg := &config{}
 	if err := envconfig.Process(ctx, cfg); err != nil {
 		return nil, err
 	}
 
+	if err := applyFlags(cfg, args); err != nil {
+		return nil, err
+	}
+
 	if err := validateConfig(cfg); err != nil {
 		return nil, err
 	}
@@ -55,13 +226,95 @@ func newConfig(ctx context.Context) (*config, error) {
 }
 
 func validateConfig(cfg *config) error {
-	if cfg.Slack.Credential == "" {
+	// Nothing is posted when only exporting a snapshot, so the Slack token is not needed
+	snapshotExportOnly := cfg.Mode == modeNotify && cfg.TeamSource.SnapshotExportOnly
+	if cfg.Slack.Credential == "" && !snapshotExportOnly {
 		return fmt.Errorf("missing Slack API token")
 	}
 
-	if cfg.NaisAPI.Credential == "" {
-		return fmt.Errorf("missing Nais API token")
+	if cfg.FailureThreshold < 0 || cfg.FailureThreshold > 1 {
+		return fmt.Errorf("invalid failure threshold: %v", cfg.FailureThreshold)
+	}
+
+	switch cfg.Mode {
+	case modeNotify:
+		return validateNotifyConfig(cfg)
+	case modeFollowUp, modeCheckAcks:
+		if cfg.Ledger.Path == "" {
+			return fmt.Errorf("%s requires a ledger path", cfg.Mode)
+		}
+
+		if cfg.Ack.StorePath == "" {
+			return fmt.Errorf("%s requires an acknowledgement store path", cfg.Mode)
+		}
+
+		if cfg.Ack.Reaction == "" {
+			return fmt.Errorf("missing acknowledgement reaction")
+		}
+
+		if cfg.FollowUp.AfterDays < 1 || cfg.FollowUp.EscalateAfterDays < 1 {
+			return fmt.Errorf("the number of days before following up must be at least 1")
+		}
+
+		return validateNotifyConfig(cfg)
+	case modeServe:
+		if cfg.Ack.SigningSecret == "" {
+			return fmt.Errorf("missing Slack signing secret")
+		}
+
+		if cfg.Ack.StorePath == "" {
+			return fmt.Errorf("missing acknowledgement store path")
+		}
+
+		// The team source is needed to check that the answers come from the owners of the teams
+		return validateNotifyConfig(cfg)
+	default:
+		return fmt.Errorf("unsupported mode: %q", cfg.Mode)
+	}
+}
+
+func validateNotifyConfig(cfg *config) error {
+	switch cfg.TeamSource.Kind {
+	case teamSourceNaisAPI:
+		if cfg.NaisAPI.Credential == "" {
+			return fmt.Errorf("missing Nais API token")
+		}
+	case teamSourceFile, teamSourceSnapshot:
+		if cfg.TeamSource.Path == "" {
+			return fmt.Errorf("missing path for team source %q", cfg.TeamSource.Kind)
+		}
+	default:
+		return fmt.Errorf("unsupported team source: %q", cfg.TeamSource.Kind)
+	}
+
+	if _, err := newTeamFilter(cfg); err != nil {
+		return fmt.Errorf("invalid team filter: %w", err)
+	}
+
+	if cfg.TeamSource.SnapshotExportOnly && cfg.TeamSource.SnapshotExportPath == "" {
+		return fmt.Errorf("snapshot export only requires a snapshot export path")
+	}
+
+	partialDataPolicy, err := naisapi.ParsePartialDataPolicy(cfg.NaisAPI.PartialDataPolicy)
+	if err != nil {
+		return err
+	}
+	cfg.NaisAPI.partialDataPolicy = partialDataPolicy
+
+	if cfg.NaisAPI.MaxRetries < 0 {
+		return fmt.Errorf("invalid number of Nais API retries: %d", cfg.NaisAPI.MaxRetries)
+	}
+
+	if cfg.Channels.SMTPHost != "" && cfg.Channels.SMTPFrom == "" {
+		return fmt.Errorf("the email channel requires a sender address")
 	}
 
 	return nil
 }
+
+func newTeamFilter(cfg *config) (*teamfilter.Filter, error) {
+	return teamfilter.New(
+		append(slices.Clone(cfg.TeamSource.Include), cfg.NaisAPI.TeamsFilter...),
+		cfg.TeamSource.Exclude,
+	)
+}
@@ -0,0 +1,48 @@
+package slackteamsnotification
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNewConfig_SlackToken(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       map[string]string
+		expectErr bool
+	}{
+		{
+			name: "notify",
+			env:  map[string]string{"SLACK_API_TOKEN": "token"},
+		},
+		{
+			name:      "notify without token",
+			expectErr: true,
+		},
+		{
+			name:      "snapshot export without token",
+			env:       map[string]string{"SNAPSHOT_EXPORT_PATH": "snapshots"},
+			expectErr: true,
+		},
+		{
+			name: "snapshot export only without token",
+			env:  map[string]string{"SNAPSHOT_EXPORT_PATH": "snapshots", "SNAPSHOT_EXPORT_ONLY": "true"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NAIS_API_TOKEN", "token")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			_, err := newConfig(context.Background(), nil)
+			if tt.expectErr && err == nil {
+				t.Error("expected error")
+			} else if !tt.expectErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
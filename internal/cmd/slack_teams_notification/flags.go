@@ -0,0 +1,25 @@
+package slackteamsnotification
+
+import (
+	"flag"
+	"os"
+)
+
+// applyFlags overrides the configuration from the environment with the command line flags in args. The first argument
+// after the flags, if any, is the mode.
+func applyFlags(cfg *config, args []string) error {
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", cfg.Slack.DryRun, "render every message without posting it to Slack (env DRY_RUN)")
+	dryRunOutput := fs.String("dry-run-output", cfg.Slack.DryRunOutput, "file to write the rendered messages to, stdout if empty (env DRY_RUN_OUTPUT)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg.Slack.DryRun = *dryRun
+	cfg.Slack.DryRunOutput = *dryRunOutput
+	if fs.NArg() > 0 {
+		cfg.Mode = fs.Arg(0)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package slackteamsnotification
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/nais/slack-teams-notification/internal/slack"
+	"github.com/nais/slack-teams-notification/internal/teamsource"
+	"github.com/sirupsen/logrus"
+)
+
+// followUp follows up the teams whose owners have not answered the latest reminder in the ledger.
+func followUp(ctx context.Context, cfg *config, log logrus.FieldLogger) error {
+	output, closeOutput, err := dryRunOutput(cfg, log)
+	if err != nil {
+		return err
+	}
+	defer closeOutput()
+
+	notifier := newNotifier(cfg, output, log)
+
+	if err := notifier.CheckAuth(ctx, slack.NotifyScopes...); err != nil {
+		return err
+	}
+
+	source, err := newTeamSource(cfg, log)
+	if err != nil {
+		return err
+	}
+
+	if err := teamsource.Check(ctx, source); err != nil {
+		return err
+	}
+
+	policy := slack.FollowUpPolicy{
+		After:         time.Duration(cfg.FollowUp.AfterDays) * 24 * time.Hour,
+		EscalateAfter: time.Duration(cfg.FollowUp.EscalateAfterDays) * 24 * time.Hour,
+	}
+
+	return notifier.FollowUp(ctx, teamsource.Stream(ctx, source), policy)
+}
+
+// checkAcks records reactions from the owners to the messages about their teams as answers.
+func checkAcks(ctx context.Context, cfg *config, log logrus.FieldLogger) error {
+	output, closeOutput, err := dryRunOutput(cfg, log)
+	if err != nil {
+		return err
+	}
+	defer closeOutput()
+
+	notifier := newNotifier(cfg, output, log)
+
+	if err := notifier.CheckAuth(ctx, slices.Concat(slack.NotifyScopes, slack.ReactionScopes)...); err != nil {
+		return err
+	}
+
+	source, err := newTeamSource(cfg, log)
+	if err != nil {
+		return err
+	}
+
+	if err := teamsource.Check(ctx, source); err != nil {
+		return err
+	}
+
+	return notifier.CheckReactions(ctx, teamsource.Stream(ctx, source), cfg.Ack.Reaction)
+}
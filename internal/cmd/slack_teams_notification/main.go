@@ -2,11 +2,20 @@ package slackteamsnotification
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"iter"
 	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/nais/slack-teams-notification/internal/acks"
+	"github.com/nais/slack-teams-notification/internal/ledger"
 	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/nais/slack-teams-notification/internal/notify"
 	"github.com/nais/slack-teams-notification/internal/slack"
+	"github.com/nais/slack-teams-notification/internal/teamsource"
 	"github.com/sirupsen/logrus"
 )
 
@@ -16,6 +25,9 @@ const (
 	exitCodeConfigError
 	exitCodeLoggerError
 	exitCodeRunError
+	exitCodePartialFailure
+	exitCodeSlackAuthError
+	exitCodeNaisAPIError
 )
 
 func Run(ctx context.Context) {
@@ -27,7 +39,7 @@ func Run(ctx context.Context) {
 		os.Exit(exitCodeEnvFileError)
 	}
 
-	cfg, err := newConfig(ctx)
+	cfg, err := newConfig(ctx, os.Args[1:])
 	if err != nil {
 		log.WithError(err).Errorf("error when loading config")
 		os.Exit(exitCodeConfigError)
@@ -39,29 +51,332 @@ func Run(ctx context.Context) {
 		os.Exit(exitCodeLoggerError)
 	}
 
-	if err := run(ctx, cfg, appLogger); err != nil {
-		appLogger.WithError(err).Errorf("error in run()")
-		os.Exit(exitCodeRunError)
+	switch cfg.Mode {
+	case modeServe:
+		err = serve(ctx, cfg, appLogger)
+	case modeFollowUp:
+		err = followUp(ctx, cfg, appLogger)
+	case modeCheckAcks:
+		err = checkAcks(ctx, cfg, appLogger)
+	default:
+		err = run(ctx, cfg, appLogger)
 	}
 
-	os.Exit(exitCodeSuccess)
+	code := exitCode(err)
+	if message, ok := exitMessages[code]; ok {
+		appLogger.WithError(err).Error(message)
+	} else if err != nil {
+		appLogger.WithError(err).Errorf("error in %s mode", cfg.Mode)
+	}
+
+	os.Exit(code)
+}
+
+// exitMessages are logged together with the errors that have their own exit code.
+var exitMessages = map[int]string{
+	exitCodeNaisAPIError:   "unable to use the Nais API, check the endpoint and the token",
+	exitCodeSlackAuthError: "unable to use the Slack API, check the Slack token",
+	exitCodePartialFailure: "too many teams could not be notified",
+}
+
+// exitCode returns the exit code for the error returned by a mode.
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return exitCodeSuccess
+	case errors.Is(err, naisapi.ErrPreflight):
+		return exitCodeNaisAPIError
+	case errors.Is(err, slack.ErrFatal):
+		return exitCodeSlackAuthError
+	case errors.Is(err, errTooManyFailures):
+		return exitCodePartialFailure
+	default:
+		return exitCodeRunError
+	}
 }
 
 func run(ctx context.Context, cfg *config, log logrus.FieldLogger) error {
-	naisTeams, err := naisapi.
-		NewClient(cfg.NaisAPI.Endpoint, cfg.NaisAPI.Credential, log.WithField("component", "nais-api-client")).
-		GetTeams(ctx, cfg.NaisAPI.TeamsFilter)
+	output, closeOutput, err := dryRunOutput(cfg, log)
+	if err != nil {
+		return err
+	}
+	defer closeOutput()
+
+	notifier := newNotifier(cfg, output, log)
+
+	// Nothing is posted when only exporting a snapshot, so the Slack token is not needed
+	if !cfg.TeamSource.SnapshotExportOnly {
+		if err := notifier.CheckAuth(ctx, slack.NotifyScopes...); err != nil {
+			return err
+		}
+	}
+
+	source, err := newTeamSource(cfg, log)
+	if err != nil {
+		return err
+	}
+
+	if err := teamsource.Check(ctx, source); err != nil {
+		return err
+	}
+
+	teams := teamsource.Stream(ctx, source)
+	if cfg.TeamSource.SnapshotExportPath != "" {
+		// The snapshot needs every team, so streaming is not possible when exporting
+		naisTeams, err := source.Teams(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(naisTeams) == 0 {
+			return errNoTeams
+		}
+
+		path, err := teamsource.WriteSnapshot(cfg.TeamSource.SnapshotExportPath, naisTeams, time.Now())
+		if err != nil {
+			return err
+		}
+
+		log.WithFields(logrus.Fields{
+			"path":       path,
+			"team_count": len(naisTeams),
+		}).Infof("wrote snapshot of teams")
+
+		if cfg.TeamSource.SnapshotExportOnly {
+			return nil
+		}
+
+		teams = naisapi.Values(naisTeams)
+	}
+
+	// Unless the owners get a digest, the teams that prefer Slack are notified one at a time by the router, like the
+	// teams that prefer another channel
+	var slackNotifier notify.Notifier
+	details := &slack.Result{}
+	if !cfg.Slack.Digest {
+		slackNotifier = notifier.TeamNotifier(details)
+	}
+
+	router, err := newRouter(cfg, slackNotifier, output, log)
 	if err != nil {
 		return err
 	}
 
-	if len(naisTeams) == 0 {
-		return fmt.Errorf("no Nais teams returned from the API, this is most likely an error")
+	teamCount := 0
+	result, err := notifier.NotifyTeams(ctx, router.Route(ctx, countTeams(teams, &teamCount)))
+
+	// The summary is logged after every team has been added, including when the run was stopped by an error
+	result.Merge(details)
+	addRouted(result, router.Result())
+	result.Log(log)
+	if err != nil {
+		return err
 	}
 
-	slack.
-		NewNotifier(cfg.Slack.Credential, cfg.NaisAPI.ConsoleURL, log.WithField("component", "slack-notifier")).
-		NotifyTeams(ctx, naisTeams)
+	if teamCount == 0 {
+		return errNoTeams
+	}
+
+	notifier.PostSummary(ctx, result)
+
+	return checkFailures(result, cfg.FailureThreshold)
+}
+
+// addRouted adds the teams delivered by the router to the result of the Slack notifier.
+func addRouted(result *slack.Result, routed notify.Result) {
+	result.Merge(&slack.Result{
+		Notified:        routed.Notified,
+		Failed:          routed.Failed,
+		AlreadyNotified: routed.AlreadyNotified,
+		Routed:          routed.Routed,
+	})
+}
+
+// checkFailures returns errTooManyFailures if the share of the teams that could not be notified, in Slack or through
+// the channel they prefer, is above the threshold.
+func checkFailures(result *slack.Result, threshold float64) error {
+	if result.FailureRatio() > threshold {
+		return fmt.Errorf(
+			"%w: %d of %d teams failed, which is more than the threshold of %.0f%%",
+			errTooManyFailures,
+			len(result.Failed),
+			len(result.Notified)+len(result.Failed),
+			threshold*100,
+		)
+	}
 
 	return nil
 }
+
+// newNotifier returns the Slack notifier. The messages are written to output instead of being posted if it is not nil.
+func newNotifier(cfg *config, output io.Writer, log logrus.FieldLogger) *slack.Notifier {
+	notifierOpts := make([]slack.NotifierOption, 0)
+
+	if cfg.Slack.Digest {
+		notifierOpts = append(notifierOpts, slack.WithDigest())
+	}
+
+	if cfg.Slack.UserCachePath != "" {
+		notifierOpts = append(notifierOpts, slack.WithUserCache(cfg.Slack.UserCachePath, cfg.Slack.UserCacheTTL))
+	}
+
+	if cfg.Slack.AdminChannel != "" {
+		notifierOpts = append(notifierOpts, slack.WithAdminChannel(cfg.Slack.AdminChannel))
+	}
+
+	if cfg.Ledger.Path != "" {
+		notifierOpts = append(notifierOpts, slack.WithLedger(ledger.NewFileStore(cfg.Ledger.Path)))
+	}
+
+	if cfg.Ledger.Period != "" {
+		notifierOpts = append(notifierOpts, slack.WithPeriod(cfg.Ledger.Period))
+	}
+
+	if cfg.Ack.StorePath != "" {
+		notifierOpts = append(notifierOpts, slack.WithAcknowledgements(acks.NewFileStore(cfg.Ack.StorePath)))
+	}
+
+	if cfg.Ack.Buttons {
+		notifierOpts = append(notifierOpts, slack.WithAcknowledgementButtons())
+	}
+
+	if cfg.Slack.RedirectRecipient != "" {
+		log.WithField("recipient", cfg.Slack.RedirectRecipient).Infof("redirecting all messages")
+		notifierOpts = append(notifierOpts, slack.WithRedirect(cfg.Slack.RedirectRecipient))
+	}
+
+	if output != nil {
+		notifierOpts = append(notifierOpts, slack.WithDryRun(output))
+	}
+
+	return slack.NewNotifier(cfg.Slack.Credential, cfg.NaisAPI.ConsoleURL, log.WithField("component", "slack-notifier"), notifierOpts...)
+}
+
+// newRouter returns the router that delivers the reminder of each team through the channel it prefers. The teams that
+// prefer Slack are passed on if slackNotifier is nil. The reviews of the other channels are written to output instead
+// of being delivered if it is not nil.
+func newRouter(cfg *config, slackNotifier notify.Notifier, output io.Writer, log logrus.FieldLogger) (*notify.Router, error) {
+	routerLog := log.WithField("component", "notification-router")
+
+	var preferences *notify.Preferences
+	if cfg.Channels.PreferencesPath != "" {
+		if cfg.Slack.RedirectRecipient != "" {
+			// Redirecting is used to check the messages in a test workspace, so nothing may reach the real teams
+			log.Warnf("channel preferences are ignored when redirecting, every team is notified in Slack")
+		} else {
+			var err error
+			if preferences, err = notify.LoadPreferences(cfg.Channels.PreferencesPath); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	notifiers := make(map[notify.Channel]notify.Notifier)
+	if slackNotifier != nil {
+		notifiers[notify.ChannelSlack] = slackNotifier
+	}
+
+	if output != nil {
+		notifiers[notify.ChannelEmail] = notify.NewDryRun(notify.ChannelEmail, output)
+		notifiers[notify.ChannelWebhook] = notify.NewDryRun(notify.ChannelWebhook, output)
+		return notify.NewRouter(preferences, notifiers, cfg.NaisAPI.ConsoleURL, routerLog)
+	}
+
+	// Like in Slack, the ledger is only used when the reminders reach the teams, so not in a dry run
+	routerOpts := make([]notify.RouterOption, 0)
+	if cfg.Ledger.Path != "" {
+		routerOpts = append(routerOpts, notify.WithLedger(ledger.NewFileStore(cfg.Ledger.Path)))
+	}
+
+	if cfg.Ledger.Period != "" {
+		routerOpts = append(routerOpts, notify.WithPeriod(cfg.Ledger.Period))
+	}
+
+	webhook, err := notify.NewWebhook(cfg.Channels.WebhookURL, preferences)
+	if err != nil {
+		return nil, err
+	}
+	notifiers[notify.ChannelWebhook] = webhook
+
+	if cfg.Channels.SMTPHost != "" {
+		notifiers[notify.ChannelEmail] = notify.NewEmail(notify.SMTPConfig{
+			Host:     cfg.Channels.SMTPHost,
+			Port:     cfg.Channels.SMTPPort,
+			Username: cfg.Channels.SMTPUsername,
+			Password: cfg.Channels.SMTPPassword,
+			From:     cfg.Channels.SMTPFrom,
+		}, preferences)
+	}
+
+	return notify.NewRouter(preferences, notifiers, cfg.NaisAPI.ConsoleURL, routerLog, routerOpts...)
+}
+
+// dryRunOutput opens the file the messages of a dry run are written to, or stdout if no file is configured. The
+// returned writer is nil if the run is not a dry run.
+func dryRunOutput(cfg *config, log logrus.FieldLogger) (io.Writer, func(), error) {
+	if !cfg.Slack.DryRun {
+		return nil, func() {}, nil
+	}
+
+	log.WithField("output", cfg.Slack.DryRunOutput).Infof("dry run, messages will not be delivered")
+	if cfg.Slack.DryRunOutput == "" {
+		return os.Stdout, func() {}, nil
+	}
+
+	f, err := os.Create(filepath.Clean(cfg.Slack.DryRunOutput))
+	if err != nil {
+		return nil, nil, fmt.Errorf("create dry run output: %w", err)
+	}
+
+	return f, func() { _ = f.Close() }, nil
+}
+
+var (
+	errNoTeams         = fmt.Errorf("no Nais teams returned from the team source, this is most likely an error")
+	errTooManyFailures = fmt.Errorf("too many teams could not be notified")
+)
+
+// countTeams returns an iterator that passes the teams through while counting them in count.
+func countTeams(teams iter.Seq2[naisapi.Team, error], count *int) iter.Seq2[naisapi.Team, error] {
+	return func(yield func(naisapi.Team, error) bool) {
+		for team, err := range teams {
+			if err == nil {
+				*count++
+			}
+
+			if !yield(team, err) {
+				return
+			}
+		}
+	}
+}
+
+func newTeamSource(cfg *config, log logrus.FieldLogger) (teamsource.TeamSource, error) {
+	log.WithField("team_source", cfg.TeamSource.Kind).Infof("using team source")
+
+	filter, err := newTeamFilter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceLog := log.WithField("component", "team-source")
+	switch cfg.TeamSource.Kind {
+	case teamSourceFile:
+		return teamsource.NewFile(cfg.TeamSource.Path, filter, sourceLog), nil
+	case teamSourceSnapshot:
+		return teamsource.NewSnapshot(cfg.TeamSource.Path, filter, sourceLog), nil
+	}
+
+	client := naisapi.NewClient(
+		cfg.NaisAPI.Endpoint,
+		cfg.NaisAPI.Credential,
+		log.WithField("component", "nais-api-client"),
+		naisapi.WithRetryConfig(naisapi.RetryConfig{
+			MaxRetries:     cfg.NaisAPI.MaxRetries,
+			InitialBackoff: cfg.NaisAPI.RetryInitialBackoff,
+			MaxBackoff:     cfg.NaisAPI.RetryMaxBackoff,
+		}),
+		naisapi.WithPartialDataPolicy(cfg.NaisAPI.partialDataPolicy),
+	)
+	return teamsource.NewNaisAPI(client, filter, sourceLog), nil
+}
@@ -0,0 +1,96 @@
+package slackteamsnotification
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/nais/slack-teams-notification/internal/notify"
+	"github.com/nais/slack-teams-notification/internal/slack"
+)
+
+func TestCheckFailures(t *testing.T) {
+	tests := []struct {
+		name      string
+		result    *slack.Result
+		routed    notify.Result
+		threshold float64
+		expectErr bool
+	}{
+		{
+			name:      "no teams",
+			result:    &slack.Result{},
+			threshold: 0.1,
+		},
+		{
+			name:      "no failures",
+			result:    &slack.Result{Notified: []string{"team1", "team2"}},
+			routed:    notify.Result{Notified: []string{"team3"}},
+			threshold: 0,
+		},
+		{
+			name:      "below threshold",
+			result:    &slack.Result{Notified: []string{"team1", "team2", "team3", "team4"}, Failed: []string{"team5"}},
+			threshold: 0.25,
+		},
+		{
+			name:      "at threshold",
+			result:    &slack.Result{Notified: []string{"team1", "team2", "team3"}, Failed: []string{"team4"}},
+			threshold: 0.25,
+		},
+		{
+			name:      "above threshold",
+			result:    &slack.Result{Notified: []string{"team1", "team2"}, Failed: []string{"team3"}},
+			threshold: 0.25,
+			expectErr: true,
+		},
+		{
+			name:      "routed failures count",
+			result:    &slack.Result{Notified: []string{"team1", "team2", "team3"}},
+			routed:    notify.Result{Failed: []string{"team4", "team5"}},
+			threshold: 0.25,
+			expectErr: true,
+		},
+		{
+			name:      "routed deliveries count",
+			result:    &slack.Result{Failed: []string{"team1"}},
+			routed:    notify.Result{Notified: []string{"team2", "team3", "team4"}},
+			threshold: 0.25,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addRouted(tt.result, tt.routed)
+			err := checkFailures(tt.result, tt.threshold)
+			if tt.expectErr && !errors.Is(err, errTooManyFailures) {
+				t.Errorf("expected errTooManyFailures, got: %v", err)
+			} else if !tt.expectErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "success", expected: exitCodeSuccess},
+		{name: "run error", err: errNoTeams, expected: exitCodeRunError},
+		{name: "too many failures", err: fmt.Errorf("%w: 2 of 3 teams failed", errTooManyFailures), expected: exitCodePartialFailure},
+		{name: "Slack auth error", err: fmt.Errorf("check auth: %w", slack.ErrFatal), expected: exitCodeSlackAuthError},
+		{name: "Nais API error", err: fmt.Errorf("check team source: %w", naisapi.ErrPreflight), expected: exitCodeNaisAPIError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := exitCode(tt.err); code != tt.expected {
+				t.Errorf("expected exit code %d, got: %d", tt.expected, code)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package slackteamsnotification
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/nais/slack-teams-notification/internal/acks"
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/nais/slack-teams-notification/internal/slack"
+	"github.com/nais/slack-teams-notification/internal/teamsource"
+	"github.com/sirupsen/logrus"
+)
+
+// serve runs an HTTP server that handles the answers from the acknowledgement buttons, until the process is
+// interrupted or terminated.
+func serve(ctx context.Context, cfg *config, log logrus.FieldLogger) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Only the owners of a team can answer for it, so the server needs the teams and the Slack users
+	notifier := newNotifier(cfg, nil, log)
+	if err := notifier.CheckAuth(ctx, slack.NotifyScopes...); err != nil {
+		return err
+	}
+
+	source, err := newTeamSource(cfg, log)
+	if err != nil {
+		return err
+	}
+
+	if err := teamsource.Check(ctx, source); err != nil {
+		return err
+	}
+
+	// The teams and the Slack users are fetched before the server starts, and are refreshed in the background, since
+	// Slack expects an answer to an interaction within 3 seconds
+	teams := &teamCache{source: source, log: log.WithField("component", "team-cache")}
+	if err := teams.refresh(ctx); err != nil {
+		return err
+	}
+
+	if err := notifier.LoadUsers(ctx); err != nil {
+		return err
+	}
+
+	store := acks.NewFileStore(cfg.Ack.StorePath)
+	mux := http.NewServeMux()
+	interactions := slack.NewInteractionHandler(cfg.Ack.SigningSecret, store, notifier, teams.lookup, log.WithField("component", "slack-interactions"))
+	mux.Handle("/slack/interactions", interactions)
+	mux.HandleFunc("/isalive", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	server := &http.Server{
+		Addr:              cfg.Ack.ListenAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+
+	log.WithField("address", cfg.Ack.ListenAddress).Infof("listening for Slack interactions")
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Infof("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = server.Shutdown(shutdownCtx)
+	interactions.Wait()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// teamCacheTTL is how long the server uses the teams before they are fetched again, so changes to the owners are seen.
+const teamCacheTTL = time.Hour
+
+// teamCache looks up teams by slug among the teams of the source.
+type teamCache struct {
+	source teamsource.TeamSource
+	log    logrus.FieldLogger
+
+	mu         sync.Mutex
+	teams      map[string]naisapi.Team
+	fetchedAt  time.Time
+	refreshing bool
+}
+
+// lookup returns the team with the given slug among the teams fetched by refresh. Once the teams are older than
+// teamCacheTTL, they are fetched again in the background, and the old teams are used until the new ones are ready.
+func (c *teamCache) lookup(_ context.Context, teamSlug string) (naisapi.Team, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.fetchedAt) > teamCacheTTL && !c.refreshing {
+		c.refreshing = true
+		go func() {
+			if err := c.refresh(context.Background()); err != nil {
+				c.log.WithError(err).Warnf("unable to refresh teams, using the old ones")
+			}
+
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.refreshing = false
+		}()
+	}
+
+	team, ok := c.teams[teamSlug]
+	return team, ok, nil
+}
+
+// refresh fetches the teams from the source.
+func (c *teamCache) refresh(ctx context.Context) error {
+	teams, err := c.source.Teams(ctx)
+	if err != nil {
+		return err
+	}
+
+	bySlug := make(map[string]naisapi.Team, len(teams))
+	for _, team := range teams {
+		bySlug[team.Slug] = team
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.teams = bySlug
+	c.fetchedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package fileutils
+
+// Lock is a no-op on platforms without flock(2). Files shared between processes are only safe to update on Unix.
+func Lock(string) (unlock func(), err error) {
+	return func() {}, nil
+}
@@ -0,0 +1,32 @@
+//go:build unix
+
+package fileutils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// Lock takes an exclusive lock on the file at path, creating it if needed, and blocks until the lock is taken. The lock
+// is held by the open file rather than the process, so it also excludes other stores in the same process, and other
+// processes sharing the file system, e.g. pods mounting the same volume. Call unlock to release it.
+func Lock(path string) (unlock func(), err error) {
+	f, err := os.OpenFile(filepath.Clean(path), os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open lock file: %w", err)
+	}
+
+	// #nosec G115 -- file descriptors fit in an int
+	fd := int(f.Fd())
+	if err := syscall.Flock(fd, syscall.LOCK_EX); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("lock %s: %w", path, err)
+	}
+
+	return func() {
+		_ = syscall.Flock(fd, syscall.LOCK_UN)
+		_ = f.Close()
+	}, nil
+}
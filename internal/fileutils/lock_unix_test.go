@@ -0,0 +1,43 @@
+//go:build unix
+
+package fileutils_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nais/slack-teams-notification/internal/fileutils"
+)
+
+func TestLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.lock")
+	unlock, err := fileutils.Lock(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	locked := make(chan struct{})
+	go func() {
+		unlockSecond, err := fileutils.Lock(path)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		} else {
+			unlockSecond()
+		}
+		close(locked)
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("expected the second lock to wait for the first one to be released")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	unlock()
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the second lock to be taken when the first one was released")
+	}
+}
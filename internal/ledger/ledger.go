@@ -0,0 +1,222 @@
+// Package ledger keeps a record of the notifications that have been delivered, so later runs know who was notified
+// about which team, when, and where the message can be found in Slack.
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nais/slack-teams-notification/internal/fileutils"
+)
+
+// ErrAlreadyDelivered is returned when a reminder is not delivered, since it has already been delivered in the period.
+var ErrAlreadyDelivered = errors.New("already delivered in this period")
+
+type Kind string
+
+const (
+	// KindReminder is the regular reminder sent to the owners of the team.
+	KindReminder Kind = "reminder"
+
+	// KindFollowUp is the second reminder sent to the owners when no one has answered the first one.
+	KindFollowUp Kind = "follow_up"
+
+	// KindTeamChannel is the escalation to the Slack channel of the team.
+	KindTeamChannel Kind = "team_channel"
+
+	// KindAdminChannel is the escalation to the admin channel.
+	KindAdminChannel Kind = "admin_channel"
+
+	// KindPreferredChannel is the regular reminder delivered to a team that prefers another channel than Slack, such as
+	// email. It is not followed up, since the answers are given in Slack.
+	KindPreferredChannel Kind = "preferred_channel"
+)
+
+// IsReminder reports whether the kind is a regular reminder, delivered in Slack or in the preferred channel of the team,
+// as opposed to a follow-up or an escalation of one.
+func (k Kind) IsReminder() bool {
+	return k == KindReminder || k == KindPreferredChannel
+}
+
+// Key identifies the delivery of reminders about a team to a recipient within a run period.
+type Key struct {
+	// Period is the run period, e.g. the month of a monthly run.
+	Period    string
+	TeamSlug  string
+	Recipient string
+}
+
+// PeriodOf returns the monthly run period of the time, e.g. "2026-10".
+func PeriodOf(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+// Delivery is a message about a team that has been delivered to a recipient.
+type Delivery struct {
+	Period   string `json:"period"`
+	TeamSlug string `json:"teamSlug"`
+	Kind     Kind   `json:"kind"`
+
+	// Recipient is the Slack user ID or channel the message was sent to, or the preferred channel of the team, e.g.
+	// "email", for reminders delivered outside Slack.
+	Recipient string `json:"recipient"`
+
+	// Channel and Timestamp identify the message in Slack. They are empty for reminders delivered outside Slack.
+	Channel   string `json:"channel"`
+	Timestamp string `json:"timestamp"`
+
+	SentAt time.Time `json:"sentAt"`
+}
+
+func (d Delivery) Key() Key {
+	return Key{Period: d.Period, TeamSlug: d.TeamSlug, Recipient: d.Recipient}
+}
+
+// Store persists deliveries.
+type Store interface {
+	// Record stores the deliveries.
+	Record(ctx context.Context, deliveries ...Delivery) error
+
+	// List returns all deliveries about the team, oldest first.
+	List(ctx context.Context, teamSlug string) ([]Delivery, error)
+
+	// Delivered reports whether a reminder has been delivered with the key. Follow-ups and escalations are ignored, so
+	// they do not stop a recipient from getting the reminder of the period.
+	Delivered(ctx context.Context, key Key) (bool, error)
+}
+
+type fileContent struct {
+	Deliveries []Delivery `json:"deliveries"`
+}
+
+// FileStore is a Store that keeps the deliveries in a JSON file. The file is created on the first write. The deliveries
+// are kept in memory, indexed by team and by key, and the file is only read again when it has been changed by someone
+// else, e.g. by another run. Writes take a lock on the file, with the suffix ".lock", and read the file again under the
+// lock, so the file can be shared between processes, e.g. the monthly run and the "follow-up" job, as long as they mount
+// the same volume.
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+
+	content   *fileContent
+	modTime   time.Time
+	size      int64
+	byTeam    map[string][]Delivery
+	reminders map[Key]bool
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (s *FileStore) Record(_ context.Context, deliveries ...Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := fileutils.Lock(s.path + ".lock")
+	if err != nil {
+		return fmt.Errorf("lock ledger: %w", err)
+	}
+	defer unlock()
+
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	content := &fileContent{Deliveries: append(slices.Clip(s.content.Deliveries), deliveries...)}
+	data, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode ledger: %w", err)
+	}
+
+	if err := fileutils.WriteAtomic(s.path, data); err != nil {
+		return fmt.Errorf("write ledger: %w", err)
+	}
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("read ledger: %w", err)
+	}
+
+	s.content, s.modTime, s.size = content, info.ModTime(), info.Size()
+	for _, delivery := range deliveries {
+		s.index(delivery)
+	}
+
+	return nil
+}
+
+func (s *FileStore) List(_ context.Context, teamSlug string) ([]Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return append(make([]Delivery, 0, len(s.byTeam[teamSlug])), s.byTeam[teamSlug]...), nil
+}
+
+func (s *FileStore) Delivered(_ context.Context, key Key) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return false, err
+	}
+
+	return s.reminders[key], nil
+}
+
+// load reads the file, unless it is unchanged since it was last read or written.
+func (s *FileStore) load() error {
+	info, err := os.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		if s.content == nil || s.size != 0 || !s.modTime.IsZero() {
+			s.reset(&fileContent{}, time.Time{}, 0)
+		}
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("read ledger: %w", err)
+	}
+
+	if s.content != nil && info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return nil
+	}
+
+	data, err := os.ReadFile(filepath.Clean(s.path))
+	if err != nil {
+		return fmt.Errorf("read ledger: %w", err)
+	}
+
+	content := &fileContent{}
+	if err := json.Unmarshal(data, content); err != nil {
+		return fmt.Errorf("decode ledger: %w", err)
+	}
+
+	s.reset(content, info.ModTime(), info.Size())
+	return nil
+}
+
+func (s *FileStore) reset(content *fileContent, modTime time.Time, size int64) {
+	s.content, s.modTime, s.size = content, modTime, size
+	s.byTeam = make(map[string][]Delivery)
+	s.reminders = make(map[Key]bool)
+	for _, delivery := range content.Deliveries {
+		s.index(delivery)
+	}
+}
+
+func (s *FileStore) index(delivery Delivery) {
+	s.byTeam[delivery.TeamSlug] = append(s.byTeam[delivery.TeamSlug], delivery)
+	if delivery.Kind.IsReminder() {
+		s.reminders[delivery.Key()] = true
+	}
+}
@@ -0,0 +1,142 @@
+package ledger_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nais/slack-teams-notification/internal/ledger"
+)
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "ledger.json")
+	sentAt := time.Date(2026, 10, 11, 10, 0, 0, 0, time.UTC)
+
+	store := ledger.NewFileStore(path)
+	if list, err := store.List(ctx, "team1"); err != nil || len(list) != 0 {
+		t.Fatalf("expected an empty ledger, got: %v, %v", list, err)
+	}
+
+	err := store.Record(
+		ctx,
+		ledger.Delivery{Period: "2026-10", TeamSlug: "team1", Kind: ledger.KindReminder, Recipient: "U1", Channel: "D1", Timestamp: "1.1", SentAt: sentAt},
+		ledger.Delivery{Period: "2026-10", TeamSlug: "team2", Kind: ledger.KindReminder, Recipient: "U1", Channel: "D1", Timestamp: "1.1", SentAt: sentAt},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = store.Record(ctx, ledger.Delivery{TeamSlug: "team1", Kind: ledger.KindFollowUp, Recipient: "U1", SentAt: sentAt.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A new store reads what the first one wrote
+	list, err := ledger.NewFileStore(path).List(ctx, "team1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(list) != 2 || list[0].Kind != ledger.KindReminder || list[0].Timestamp != "1.1" || list[1].Kind != ledger.KindFollowUp {
+		t.Fatalf("unexpected deliveries: %v", list)
+	}
+}
+
+func TestFileStore_Delivered(t *testing.T) {
+	ctx := context.Background()
+	store := ledger.NewFileStore(filepath.Join(t.TempDir(), "ledger.json"))
+
+	err := store.Record(
+		ctx,
+		ledger.Delivery{Period: "2026-10", TeamSlug: "team1", Kind: ledger.KindReminder, Recipient: "U1"},
+		ledger.Delivery{Period: "2026-10", TeamSlug: "team1", Kind: ledger.KindPreferredChannel, Recipient: "email"},
+		ledger.Delivery{Period: "2026-10", TeamSlug: "team1", Kind: ledger.KindFollowUp, Recipient: "U2"},
+		ledger.Delivery{Period: "2026-10", TeamSlug: "team1", Kind: ledger.KindTeamChannel, Recipient: "C1"},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := map[ledger.Key]bool{
+		{Period: "2026-10", TeamSlug: "team1", Recipient: "U1"}:    true,
+		{Period: "2026-11", TeamSlug: "team1", Recipient: "U1"}:    false,
+		{Period: "2026-10", TeamSlug: "team2", Recipient: "U1"}:    false,
+		{Period: "2026-10", TeamSlug: "team1", Recipient: "U2"}:    false,
+		{Period: "2026-10", TeamSlug: "team1", Recipient: "email"}: true,
+		{Period: "2026-10", TeamSlug: "team1", Recipient: "C1"}:    false,
+	}
+
+	for key, expected := range tests {
+		delivered, err := store.Delivered(ctx, key)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if delivered != expected {
+			t.Errorf("expected delivered to be %v for %+v, got: %v", expected, key, delivered)
+		}
+	}
+}
+
+func TestFileStore_WrittenByOther(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "ledger.json")
+	key := ledger.Key{Period: "2026-10", TeamSlug: "team1", Recipient: "U1"}
+
+	store := ledger.NewFileStore(path)
+	if delivered, err := store.Delivered(ctx, key); err != nil || delivered {
+		t.Fatalf("expected nothing to be delivered, got: %v, %v", delivered, err)
+	}
+
+	// Another run records a delivery in the same file
+	err := ledger.NewFileStore(path).Record(ctx, ledger.Delivery{Period: "2026-10", TeamSlug: "team1", Kind: ledger.KindReminder, Recipient: "U1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if delivered, err := store.Delivered(ctx, key); err != nil || !delivered {
+		t.Fatalf("expected the delivery of the other run to be seen, got: %v, %v", delivered, err)
+	}
+
+	if err := store.Record(ctx, ledger.Delivery{Period: "2026-10", TeamSlug: "team1", Kind: ledger.KindFollowUp, Recipient: "U1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if list, err := ledger.NewFileStore(path).List(ctx, "team1"); err != nil || len(list) != 2 {
+		t.Fatalf("expected both deliveries to be kept, got: %v, %v", list, err)
+	}
+}
+
+func TestFileStore_ConcurrentWriters(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "ledger.json")
+
+	// Each store stands in for a separate process, e.g. the monthly run and the follow-up job, sharing the file
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Go(func() {
+			store := ledger.NewFileStore(path)
+			for j := range 5 {
+				delivery := ledger.Delivery{Period: "2026-10", TeamSlug: "team1", Kind: ledger.KindReminder, Recipient: fmt.Sprintf("U%d-%d", i, j)}
+				if err := store.Record(ctx, delivery); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	if list, err := ledger.NewFileStore(path).List(ctx, "team1"); err != nil || len(list) != 50 {
+		t.Fatalf("expected every delivery to be kept, got %d: %v", len(list), err)
+	}
+}
+
+func TestPeriodOf(t *testing.T) {
+	if period := ledger.PeriodOf(time.Date(2026, 10, 31, 23, 30, 0, 0, time.FixedZone("UTC-2", -2*60*60))); period != "2026-11" {
+		t.Errorf("expected period 2026-11, got: %s", period)
+	}
+}
@@ -0,0 +1,70 @@
+package naisapi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PartialDataPolicy decides what happens when the Nais API responds with both data and errors.
+type PartialDataPolicy string
+
+const (
+	// PartialDataFail treats a response with errors as failed, even if it contains data.
+	PartialDataFail PartialDataPolicy = "fail"
+
+	// PartialDataWarn logs the errors of a response as warnings and continues with the data that was returned.
+	PartialDataWarn PartialDataPolicy = "warn"
+)
+
+// ParsePartialDataPolicy parses a partial data policy from its string representation.
+func ParsePartialDataPolicy(policy string) (PartialDataPolicy, error) {
+	switch p := PartialDataPolicy(strings.ToLower(policy)); p {
+	case PartialDataFail, PartialDataWarn:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unsupported partial data policy: %q", policy)
+	}
+}
+
+// GraphQLError is an error returned in the errors array of a GraphQL response.
+type GraphQLError struct {
+	Message    string         `json:"message"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+func (e GraphQLError) Error() string {
+	if len(e.Path) == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("%s (path: %s)", e.Message, e.PathString())
+}
+
+// PathString returns the path of the error in dotted notation, e.g. "teams.nodes.3.members".
+func (e GraphQLError) PathString() string {
+	elements := make([]string, len(e.Path))
+	for i, element := range e.Path {
+		elements[i] = fmt.Sprint(element)
+	}
+	return strings.Join(elements, ".")
+}
+
+// GraphQLErrors is the errors array of a GraphQL response.
+type GraphQLErrors []GraphQLError
+
+func (e GraphQLErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap makes it possible to use errors.As to get a single GraphQLError.
+func (e GraphQLErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
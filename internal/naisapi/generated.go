@@ -0,0 +1,776 @@
+// Code generated by github.com/Khan/genqlient, DO NOT EDIT.
+
+package naisapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+type TeamMemberRole string
+
+const (
+	TeamMemberRoleMember TeamMemberRole = "MEMBER"
+	TeamMemberRoleOwner  TeamMemberRole = "OWNER"
+)
+
+var AllTeamMemberRole = []TeamMemberRole{
+	TeamMemberRoleMember,
+	TeamMemberRoleOwner,
+}
+
+// __getTeamMembersInput is used internally by genqlient
+type __getTeamMembersInput struct {
+	Slug          string  `json:"slug"`
+	MembersCursor *string `json:"membersCursor"`
+}
+
+// GetSlug returns __getTeamMembersInput.Slug, and is useful for accessing the field via an interface.
+func (v *__getTeamMembersInput) GetSlug() string { return v.Slug }
+
+// GetMembersCursor returns __getTeamMembersInput.MembersCursor, and is useful for accessing the field via an interface.
+func (v *__getTeamMembersInput) GetMembersCursor() *string { return v.MembersCursor }
+
+// __getTeamsAndMembersInput is used internally by genqlient
+type __getTeamsAndMembersInput struct {
+	TeamsCursor *string `json:"teamsCursor"`
+}
+
+// GetTeamsCursor returns __getTeamsAndMembersInput.TeamsCursor, and is useful for accessing the field via an interface.
+func (v *__getTeamsAndMembersInput) GetTeamsCursor() *string { return v.TeamsCursor }
+
+// getTeamMembersResponse is returned by getTeamMembers on success.
+type getTeamMembersResponse struct {
+	Team getTeamMembersTeam `json:"team"`
+}
+
+// GetTeam returns getTeamMembersResponse.Team, and is useful for accessing the field via an interface.
+func (v *getTeamMembersResponse) GetTeam() getTeamMembersTeam { return v.Team }
+
+// getTeamMembersTeam includes the requested fields of the GraphQL type Team.
+type getTeamMembersTeam struct {
+	Members getTeamMembersTeamMembersTeamMemberConnection `json:"members"`
+}
+
+// GetMembers returns getTeamMembersTeam.Members, and is useful for accessing the field via an interface.
+func (v *getTeamMembersTeam) GetMembers() getTeamMembersTeamMembersTeamMemberConnection {
+	return v.Members
+}
+
+// getTeamMembersTeamMembersTeamMemberConnection includes the requested fields of the GraphQL type TeamMemberConnection.
+type getTeamMembersTeamMembersTeamMemberConnection struct {
+	teamMemberPage `json:"-"`
+}
+
+// GetPageInfo returns getTeamMembersTeamMembersTeamMemberConnection.PageInfo, and is useful for accessing the field via an interface.
+func (v *getTeamMembersTeamMembersTeamMemberConnection) GetPageInfo() teamMemberPagePageInfo {
+	return v.teamMemberPage.PageInfo
+}
+
+// GetNodes returns getTeamMembersTeamMembersTeamMemberConnection.Nodes, and is useful for accessing the field via an interface.
+func (v *getTeamMembersTeamMembersTeamMemberConnection) GetNodes() []teamMemberNode {
+	return v.teamMemberPage.Nodes
+}
+
+func (v *getTeamMembersTeamMembersTeamMemberConnection) UnmarshalJSON(b []byte) error {
+
+	if string(b) == "null" {
+		return nil
+	}
+
+	var firstPass struct {
+		*getTeamMembersTeamMembersTeamMemberConnection
+		graphql.NoUnmarshalJSON
+	}
+	firstPass.getTeamMembersTeamMembersTeamMemberConnection = v
+
+	err := json.Unmarshal(b, &firstPass)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(
+		b, &v.teamMemberPage)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+type __premarshalgetTeamMembersTeamMembersTeamMemberConnection struct {
+	PageInfo teamMemberPagePageInfo `json:"pageInfo"`
+
+	Nodes []teamMemberNode `json:"nodes"`
+}
+
+func (v *getTeamMembersTeamMembersTeamMemberConnection) MarshalJSON() ([]byte, error) {
+	premarshaled, err := v.__premarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(premarshaled)
+}
+
+func (v *getTeamMembersTeamMembersTeamMemberConnection) __premarshalJSON() (*__premarshalgetTeamMembersTeamMembersTeamMemberConnection, error) {
+	var retval __premarshalgetTeamMembersTeamMembersTeamMemberConnection
+
+	retval.PageInfo = v.teamMemberPage.PageInfo
+	retval.Nodes = v.teamMemberPage.Nodes
+	return &retval, nil
+}
+
+// getTeamsAndMembersResponse is returned by getTeamsAndMembers on success.
+type getTeamsAndMembersResponse struct {
+	Teams getTeamsAndMembersTeamsTeamConnection `json:"teams"`
+}
+
+// GetTeams returns getTeamsAndMembersResponse.Teams, and is useful for accessing the field via an interface.
+func (v *getTeamsAndMembersResponse) GetTeams() getTeamsAndMembersTeamsTeamConnection { return v.Teams }
+
+// getTeamsAndMembersTeamsTeamConnection includes the requested fields of the GraphQL type TeamConnection.
+type getTeamsAndMembersTeamsTeamConnection struct {
+	PageInfo getTeamsAndMembersTeamsTeamConnectionPageInfo `json:"pageInfo"`
+	Nodes    []teamNode                                    `json:"nodes"`
+}
+
+// GetPageInfo returns getTeamsAndMembersTeamsTeamConnection.PageInfo, and is useful for accessing the field via an interface.
+func (v *getTeamsAndMembersTeamsTeamConnection) GetPageInfo() getTeamsAndMembersTeamsTeamConnectionPageInfo {
+	return v.PageInfo
+}
+
+// GetNodes returns getTeamsAndMembersTeamsTeamConnection.Nodes, and is useful for accessing the field via an interface.
+func (v *getTeamsAndMembersTeamsTeamConnection) GetNodes() []teamNode { return v.Nodes }
+
+// getTeamsAndMembersTeamsTeamConnectionPageInfo includes the requested fields of the GraphQL type PageInfo.
+type getTeamsAndMembersTeamsTeamConnectionPageInfo struct {
+	TotalCount  int    `json:"totalCount"`
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// GetTotalCount returns getTeamsAndMembersTeamsTeamConnectionPageInfo.TotalCount, and is useful for accessing the field via an interface.
+func (v *getTeamsAndMembersTeamsTeamConnectionPageInfo) GetTotalCount() int { return v.TotalCount }
+
+// GetHasNextPage returns getTeamsAndMembersTeamsTeamConnectionPageInfo.HasNextPage, and is useful for accessing the field via an interface.
+func (v *getTeamsAndMembersTeamsTeamConnectionPageInfo) GetHasNextPage() bool { return v.HasNextPage }
+
+// GetEndCursor returns getTeamsAndMembersTeamsTeamConnectionPageInfo.EndCursor, and is useful for accessing the field via an interface.
+func (v *getTeamsAndMembersTeamsTeamConnectionPageInfo) GetEndCursor() string { return v.EndCursor }
+
+// preflightMeAuthenticatedUser includes the requested fields of the GraphQL interface AuthenticatedUser.
+//
+// preflightMeAuthenticatedUser is implemented by the following types:
+// preflightMeServiceAccount
+// preflightMeUser
+type preflightMeAuthenticatedUser interface {
+	implementsGraphQLInterfacepreflightMeAuthenticatedUser()
+	// GetTypename returns the receiver's concrete GraphQL type-name (see interface doc for possible values).
+	GetTypename() string
+}
+
+func (v *preflightMeServiceAccount) implementsGraphQLInterfacepreflightMeAuthenticatedUser() {}
+func (v *preflightMeUser) implementsGraphQLInterfacepreflightMeAuthenticatedUser()           {}
+
+func __unmarshalpreflightMeAuthenticatedUser(b []byte, v *preflightMeAuthenticatedUser) error {
+	if string(b) == "null" {
+		return nil
+	}
+
+	var tn struct {
+		TypeName string `json:"__typename"`
+	}
+	err := json.Unmarshal(b, &tn)
+	if err != nil {
+		return err
+	}
+
+	switch tn.TypeName {
+	case "ServiceAccount":
+		*v = new(preflightMeServiceAccount)
+		return json.Unmarshal(b, *v)
+	case "User":
+		*v = new(preflightMeUser)
+		return json.Unmarshal(b, *v)
+	case "":
+		return fmt.Errorf(
+			"response was missing AuthenticatedUser.__typename")
+	default:
+		return fmt.Errorf(
+			`unexpected concrete type for preflightMeAuthenticatedUser: "%v"`, tn.TypeName)
+	}
+}
+
+func __marshalpreflightMeAuthenticatedUser(v *preflightMeAuthenticatedUser) ([]byte, error) {
+
+	var typename string
+	switch v := (*v).(type) {
+	case *preflightMeServiceAccount:
+		typename = "ServiceAccount"
+
+		result := struct {
+			TypeName string `json:"__typename"`
+			*preflightMeServiceAccount
+		}{typename, v}
+		return json.Marshal(result)
+	case *preflightMeUser:
+		typename = "User"
+
+		result := struct {
+			TypeName string `json:"__typename"`
+			*preflightMeUser
+		}{typename, v}
+		return json.Marshal(result)
+	case nil:
+		return []byte("null"), nil
+	default:
+		return nil, fmt.Errorf(
+			`unexpected concrete type for preflightMeAuthenticatedUser: "%T"`, v)
+	}
+}
+
+// preflightMeServiceAccount includes the requested fields of the GraphQL type ServiceAccount.
+type preflightMeServiceAccount struct {
+	Typename string `json:"__typename"`
+	Name     string `json:"name"`
+}
+
+// GetTypename returns preflightMeServiceAccount.Typename, and is useful for accessing the field via an interface.
+func (v *preflightMeServiceAccount) GetTypename() string { return v.Typename }
+
+// GetName returns preflightMeServiceAccount.Name, and is useful for accessing the field via an interface.
+func (v *preflightMeServiceAccount) GetName() string { return v.Name }
+
+// preflightMeUser includes the requested fields of the GraphQL type User.
+type preflightMeUser struct {
+	Typename string `json:"__typename"`
+	Name     string `json:"name"`
+}
+
+// GetTypename returns preflightMeUser.Typename, and is useful for accessing the field via an interface.
+func (v *preflightMeUser) GetTypename() string { return v.Typename }
+
+// GetName returns preflightMeUser.Name, and is useful for accessing the field via an interface.
+func (v *preflightMeUser) GetName() string { return v.Name }
+
+// preflightResponse is returned by preflight on success.
+type preflightResponse struct {
+	Me    preflightMeAuthenticatedUser `json:"-"`
+	Teams preflightTeamsTeamConnection `json:"teams"`
+}
+
+// GetMe returns preflightResponse.Me, and is useful for accessing the field via an interface.
+func (v *preflightResponse) GetMe() preflightMeAuthenticatedUser { return v.Me }
+
+// GetTeams returns preflightResponse.Teams, and is useful for accessing the field via an interface.
+func (v *preflightResponse) GetTeams() preflightTeamsTeamConnection { return v.Teams }
+
+func (v *preflightResponse) UnmarshalJSON(b []byte) error {
+
+	if string(b) == "null" {
+		return nil
+	}
+
+	var firstPass struct {
+		*preflightResponse
+		Me json.RawMessage `json:"me"`
+		graphql.NoUnmarshalJSON
+	}
+	firstPass.preflightResponse = v
+
+	err := json.Unmarshal(b, &firstPass)
+	if err != nil {
+		return err
+	}
+
+	{
+		dst := &v.Me
+		src := firstPass.Me
+		if len(src) != 0 && string(src) != "null" {
+			err = __unmarshalpreflightMeAuthenticatedUser(
+				src, dst)
+			if err != nil {
+				return fmt.Errorf(
+					"unable to unmarshal preflightResponse.Me: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+type __premarshalpreflightResponse struct {
+	Me json.RawMessage `json:"me"`
+
+	Teams preflightTeamsTeamConnection `json:"teams"`
+}
+
+func (v *preflightResponse) MarshalJSON() ([]byte, error) {
+	premarshaled, err := v.__premarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(premarshaled)
+}
+
+func (v *preflightResponse) __premarshalJSON() (*__premarshalpreflightResponse, error) {
+	var retval __premarshalpreflightResponse
+
+	{
+
+		dst := &retval.Me
+		src := v.Me
+		var err error
+		*dst, err = __marshalpreflightMeAuthenticatedUser(
+			&src)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"unable to marshal preflightResponse.Me: %w", err)
+		}
+	}
+	retval.Teams = v.Teams
+	return &retval, nil
+}
+
+// preflightTeamsTeamConnection includes the requested fields of the GraphQL type TeamConnection.
+type preflightTeamsTeamConnection struct {
+	Nodes []preflightTeamsTeamConnectionNodesTeam `json:"nodes"`
+}
+
+// GetNodes returns preflightTeamsTeamConnection.Nodes, and is useful for accessing the field via an interface.
+func (v *preflightTeamsTeamConnection) GetNodes() []preflightTeamsTeamConnectionNodesTeam {
+	return v.Nodes
+}
+
+// preflightTeamsTeamConnectionNodesTeam includes the requested fields of the GraphQL type Team.
+type preflightTeamsTeamConnectionNodesTeam struct {
+	Slug    string                                                           `json:"slug"`
+	Members preflightTeamsTeamConnectionNodesTeamMembersTeamMemberConnection `json:"members"`
+}
+
+// GetSlug returns preflightTeamsTeamConnectionNodesTeam.Slug, and is useful for accessing the field via an interface.
+func (v *preflightTeamsTeamConnectionNodesTeam) GetSlug() string { return v.Slug }
+
+// GetMembers returns preflightTeamsTeamConnectionNodesTeam.Members, and is useful for accessing the field via an interface.
+func (v *preflightTeamsTeamConnectionNodesTeam) GetMembers() preflightTeamsTeamConnectionNodesTeamMembersTeamMemberConnection {
+	return v.Members
+}
+
+// preflightTeamsTeamConnectionNodesTeamMembersTeamMemberConnection includes the requested fields of the GraphQL type TeamMemberConnection.
+type preflightTeamsTeamConnectionNodesTeamMembersTeamMemberConnection struct {
+	Nodes []preflightTeamsTeamConnectionNodesTeamMembersTeamMemberConnectionNodesTeamMember `json:"nodes"`
+}
+
+// GetNodes returns preflightTeamsTeamConnectionNodesTeamMembersTeamMemberConnection.Nodes, and is useful for accessing the field via an interface.
+func (v *preflightTeamsTeamConnectionNodesTeamMembersTeamMemberConnection) GetNodes() []preflightTeamsTeamConnectionNodesTeamMembersTeamMemberConnectionNodesTeamMember {
+	return v.Nodes
+}
+
+// preflightTeamsTeamConnectionNodesTeamMembersTeamMemberConnectionNodesTeamMember includes the requested fields of the GraphQL type TeamMember.
+type preflightTeamsTeamConnectionNodesTeamMembersTeamMemberConnectionNodesTeamMember struct {
+	Role TeamMemberRole `json:"role"`
+}
+
+// GetRole returns preflightTeamsTeamConnectionNodesTeamMembersTeamMemberConnectionNodesTeamMember.Role, and is useful for accessing the field via an interface.
+func (v *preflightTeamsTeamConnectionNodesTeamMembersTeamMemberConnectionNodesTeamMember) GetRole() TeamMemberRole {
+	return v.Role
+}
+
+// teamMemberNode includes the requested fields of the GraphQL type TeamMember.
+type teamMemberNode struct {
+	User teamMemberNodeUser `json:"user"`
+	Role TeamMemberRole     `json:"role"`
+}
+
+// GetUser returns teamMemberNode.User, and is useful for accessing the field via an interface.
+func (v *teamMemberNode) GetUser() teamMemberNodeUser { return v.User }
+
+// GetRole returns teamMemberNode.Role, and is useful for accessing the field via an interface.
+func (v *teamMemberNode) GetRole() TeamMemberRole { return v.Role }
+
+// teamMemberNodeUser includes the requested fields of the GraphQL type User.
+type teamMemberNodeUser struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// GetName returns teamMemberNodeUser.Name, and is useful for accessing the field via an interface.
+func (v *teamMemberNodeUser) GetName() string { return v.Name }
+
+// GetEmail returns teamMemberNodeUser.Email, and is useful for accessing the field via an interface.
+func (v *teamMemberNodeUser) GetEmail() string { return v.Email }
+
+// teamMemberPage includes the GraphQL fields of TeamMemberConnection requested by the fragment teamMemberPage.
+type teamMemberPage struct {
+	PageInfo teamMemberPagePageInfo `json:"pageInfo"`
+	Nodes    []teamMemberNode       `json:"nodes"`
+}
+
+// GetPageInfo returns teamMemberPage.PageInfo, and is useful for accessing the field via an interface.
+func (v *teamMemberPage) GetPageInfo() teamMemberPagePageInfo { return v.PageInfo }
+
+// GetNodes returns teamMemberPage.Nodes, and is useful for accessing the field via an interface.
+func (v *teamMemberPage) GetNodes() []teamMemberNode { return v.Nodes }
+
+// teamMemberPagePageInfo includes the requested fields of the GraphQL type PageInfo.
+type teamMemberPagePageInfo struct {
+	TotalCount  int    `json:"totalCount"`
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// GetTotalCount returns teamMemberPagePageInfo.TotalCount, and is useful for accessing the field via an interface.
+func (v *teamMemberPagePageInfo) GetTotalCount() int { return v.TotalCount }
+
+// GetHasNextPage returns teamMemberPagePageInfo.HasNextPage, and is useful for accessing the field via an interface.
+func (v *teamMemberPagePageInfo) GetHasNextPage() bool { return v.HasNextPage }
+
+// GetEndCursor returns teamMemberPagePageInfo.EndCursor, and is useful for accessing the field via an interface.
+func (v *teamMemberPagePageInfo) GetEndCursor() string { return v.EndCursor }
+
+// teamNode includes the requested fields of the GraphQL type Team.
+type teamNode struct {
+	Slug               string                                         `json:"slug"`
+	SlackChannel       string                                         `json:"slackChannel"`
+	Purpose            string                                         `json:"purpose"`
+	DeletionInProgress bool                                           `json:"deletionInProgress"`
+	Environments       []teamNodeEnvironmentsTeamEnvironment          `json:"environments"`
+	ExternalResources  teamNodeExternalResourcesTeamExternalResources `json:"externalResources"`
+	Members            teamNodeMembersTeamMemberConnection            `json:"members"`
+}
+
+// GetSlug returns teamNode.Slug, and is useful for accessing the field via an interface.
+func (v *teamNode) GetSlug() string { return v.Slug }
+
+// GetSlackChannel returns teamNode.SlackChannel, and is useful for accessing the field via an interface.
+func (v *teamNode) GetSlackChannel() string { return v.SlackChannel }
+
+// GetPurpose returns teamNode.Purpose, and is useful for accessing the field via an interface.
+func (v *teamNode) GetPurpose() string { return v.Purpose }
+
+// GetDeletionInProgress returns teamNode.DeletionInProgress, and is useful for accessing the field via an interface.
+func (v *teamNode) GetDeletionInProgress() bool { return v.DeletionInProgress }
+
+// GetEnvironments returns teamNode.Environments, and is useful for accessing the field via an interface.
+func (v *teamNode) GetEnvironments() []teamNodeEnvironmentsTeamEnvironment { return v.Environments }
+
+// GetExternalResources returns teamNode.ExternalResources, and is useful for accessing the field via an interface.
+func (v *teamNode) GetExternalResources() teamNodeExternalResourcesTeamExternalResources {
+	return v.ExternalResources
+}
+
+// GetMembers returns teamNode.Members, and is useful for accessing the field via an interface.
+func (v *teamNode) GetMembers() teamNodeMembersTeamMemberConnection { return v.Members }
+
+// teamNodeEnvironmentsTeamEnvironment includes the requested fields of the GraphQL type TeamEnvironment.
+type teamNodeEnvironmentsTeamEnvironment struct {
+	Environment  teamNodeEnvironmentsTeamEnvironmentEnvironment `json:"environment"`
+	GcpProjectID string                                         `json:"gcpProjectID"`
+}
+
+// GetEnvironment returns teamNodeEnvironmentsTeamEnvironment.Environment, and is useful for accessing the field via an interface.
+func (v *teamNodeEnvironmentsTeamEnvironment) GetEnvironment() teamNodeEnvironmentsTeamEnvironmentEnvironment {
+	return v.Environment
+}
+
+// GetGcpProjectID returns teamNodeEnvironmentsTeamEnvironment.GcpProjectID, and is useful for accessing the field via an interface.
+func (v *teamNodeEnvironmentsTeamEnvironment) GetGcpProjectID() string { return v.GcpProjectID }
+
+// teamNodeEnvironmentsTeamEnvironmentEnvironment includes the requested fields of the GraphQL type Environment.
+type teamNodeEnvironmentsTeamEnvironmentEnvironment struct {
+	Name string `json:"name"`
+}
+
+// GetName returns teamNodeEnvironmentsTeamEnvironmentEnvironment.Name, and is useful for accessing the field via an interface.
+func (v *teamNodeEnvironmentsTeamEnvironmentEnvironment) GetName() string { return v.Name }
+
+// teamNodeExternalResourcesTeamExternalResources includes the requested fields of the GraphQL type TeamExternalResources.
+type teamNodeExternalResourcesTeamExternalResources struct {
+	GitHubTeam   *teamNodeExternalResourcesTeamExternalResourcesGitHubTeamTeamGitHubTeam     `json:"gitHubTeam"`
+	GoogleGroup  *teamNodeExternalResourcesTeamExternalResourcesGoogleGroupTeamGoogleGroup   `json:"googleGroup"`
+	EntraIDGroup *teamNodeExternalResourcesTeamExternalResourcesEntraIDGroupTeamEntraIDGroup `json:"entraIDGroup"`
+}
+
+// GetGitHubTeam returns teamNodeExternalResourcesTeamExternalResources.GitHubTeam, and is useful for accessing the field via an interface.
+func (v *teamNodeExternalResourcesTeamExternalResources) GetGitHubTeam() *teamNodeExternalResourcesTeamExternalResourcesGitHubTeamTeamGitHubTeam {
+	return v.GitHubTeam
+}
+
+// GetGoogleGroup returns teamNodeExternalResourcesTeamExternalResources.GoogleGroup, and is useful for accessing the field via an interface.
+func (v *teamNodeExternalResourcesTeamExternalResources) GetGoogleGroup() *teamNodeExternalResourcesTeamExternalResourcesGoogleGroupTeamGoogleGroup {
+	return v.GoogleGroup
+}
+
+// GetEntraIDGroup returns teamNodeExternalResourcesTeamExternalResources.EntraIDGroup, and is useful for accessing the field via an interface.
+func (v *teamNodeExternalResourcesTeamExternalResources) GetEntraIDGroup() *teamNodeExternalResourcesTeamExternalResourcesEntraIDGroupTeamEntraIDGroup {
+	return v.EntraIDGroup
+}
+
+// teamNodeExternalResourcesTeamExternalResourcesEntraIDGroupTeamEntraIDGroup includes the requested fields of the GraphQL type TeamEntraIDGroup.
+type teamNodeExternalResourcesTeamExternalResourcesEntraIDGroupTeamEntraIDGroup struct {
+	GroupID string `json:"groupID"`
+}
+
+// GetGroupID returns teamNodeExternalResourcesTeamExternalResourcesEntraIDGroupTeamEntraIDGroup.GroupID, and is useful for accessing the field via an interface.
+func (v *teamNodeExternalResourcesTeamExternalResourcesEntraIDGroupTeamEntraIDGroup) GetGroupID() string {
+	return v.GroupID
+}
+
+// teamNodeExternalResourcesTeamExternalResourcesGitHubTeamTeamGitHubTeam includes the requested fields of the GraphQL type TeamGitHubTeam.
+type teamNodeExternalResourcesTeamExternalResourcesGitHubTeamTeamGitHubTeam struct {
+	Slug string `json:"slug"`
+}
+
+// GetSlug returns teamNodeExternalResourcesTeamExternalResourcesGitHubTeamTeamGitHubTeam.Slug, and is useful for accessing the field via an interface.
+func (v *teamNodeExternalResourcesTeamExternalResourcesGitHubTeamTeamGitHubTeam) GetSlug() string {
+	return v.Slug
+}
+
+// teamNodeExternalResourcesTeamExternalResourcesGoogleGroupTeamGoogleGroup includes the requested fields of the GraphQL type TeamGoogleGroup.
+type teamNodeExternalResourcesTeamExternalResourcesGoogleGroupTeamGoogleGroup struct {
+	Email string `json:"email"`
+}
+
+// GetEmail returns teamNodeExternalResourcesTeamExternalResourcesGoogleGroupTeamGoogleGroup.Email, and is useful for accessing the field via an interface.
+func (v *teamNodeExternalResourcesTeamExternalResourcesGoogleGroupTeamGoogleGroup) GetEmail() string {
+	return v.Email
+}
+
+// teamNodeMembersTeamMemberConnection includes the requested fields of the GraphQL type TeamMemberConnection.
+type teamNodeMembersTeamMemberConnection struct {
+	teamMemberPage `json:"-"`
+}
+
+// GetPageInfo returns teamNodeMembersTeamMemberConnection.PageInfo, and is useful for accessing the field via an interface.
+func (v *teamNodeMembersTeamMemberConnection) GetPageInfo() teamMemberPagePageInfo {
+	return v.teamMemberPage.PageInfo
+}
+
+// GetNodes returns teamNodeMembersTeamMemberConnection.Nodes, and is useful for accessing the field via an interface.
+func (v *teamNodeMembersTeamMemberConnection) GetNodes() []teamMemberNode {
+	return v.teamMemberPage.Nodes
+}
+
+func (v *teamNodeMembersTeamMemberConnection) UnmarshalJSON(b []byte) error {
+
+	if string(b) == "null" {
+		return nil
+	}
+
+	var firstPass struct {
+		*teamNodeMembersTeamMemberConnection
+		graphql.NoUnmarshalJSON
+	}
+	firstPass.teamNodeMembersTeamMemberConnection = v
+
+	err := json.Unmarshal(b, &firstPass)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(
+		b, &v.teamMemberPage)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+type __premarshalteamNodeMembersTeamMemberConnection struct {
+	PageInfo teamMemberPagePageInfo `json:"pageInfo"`
+
+	Nodes []teamMemberNode `json:"nodes"`
+}
+
+func (v *teamNodeMembersTeamMemberConnection) MarshalJSON() ([]byte, error) {
+	premarshaled, err := v.__premarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(premarshaled)
+}
+
+func (v *teamNodeMembersTeamMemberConnection) __premarshalJSON() (*__premarshalteamNodeMembersTeamMemberConnection, error) {
+	var retval __premarshalteamNodeMembersTeamMemberConnection
+
+	retval.PageInfo = v.teamMemberPage.PageInfo
+	retval.Nodes = v.teamMemberPage.Nodes
+	return &retval, nil
+}
+
+// The query executed by getTeamMembers.
+const getTeamMembers_Operation = `
+query getTeamMembers ($slug: Slug!, $membersCursor: Cursor) {
+	team(slug: $slug) {
+		members(first: 100, after: $membersCursor) {
+			... teamMemberPage
+		}
+	}
+}
+fragment teamMemberPage on TeamMemberConnection {
+	pageInfo {
+		totalCount
+		hasNextPage
+		endCursor
+	}
+	nodes {
+		user {
+			name
+			email
+		}
+		role
+	}
+}
+`
+
+func getTeamMembers(
+	ctx_ context.Context,
+	client_ graphql.Client,
+	slug string,
+	membersCursor *string,
+) (data_ *getTeamMembersResponse, err_ error) {
+	req_ := &graphql.Request{
+		OpName: "getTeamMembers",
+		Query:  getTeamMembers_Operation,
+		Variables: &__getTeamMembersInput{
+			Slug:          slug,
+			MembersCursor: membersCursor,
+		},
+	}
+
+	data_ = &getTeamMembersResponse{}
+	resp_ := &graphql.Response{Data: data_}
+
+	err_ = client_.MakeRequest(
+		ctx_,
+		req_,
+		resp_,
+	)
+
+	return data_, err_
+}
+
+// The query executed by getTeamsAndMembers.
+const getTeamsAndMembers_Operation = `
+query getTeamsAndMembers ($teamsCursor: Cursor) {
+	teams(first: 100, after: $teamsCursor) {
+		pageInfo {
+			totalCount
+			hasNextPage
+			endCursor
+		}
+		nodes {
+			slug
+			slackChannel
+			purpose
+			deletionInProgress
+			environments {
+				environment {
+					name
+				}
+				gcpProjectID
+			}
+			externalResources {
+				gitHubTeam {
+					slug
+				}
+				googleGroup {
+					email
+				}
+				entraIDGroup {
+					groupID
+				}
+			}
+			members(first: 100) {
+				... teamMemberPage
+			}
+		}
+	}
+}
+fragment teamMemberPage on TeamMemberConnection {
+	pageInfo {
+		totalCount
+		hasNextPage
+		endCursor
+	}
+	nodes {
+		user {
+			name
+			email
+		}
+		role
+	}
+}
+`
+
+func getTeamsAndMembers(
+	ctx_ context.Context,
+	client_ graphql.Client,
+	teamsCursor *string,
+) (data_ *getTeamsAndMembersResponse, err_ error) {
+	req_ := &graphql.Request{
+		OpName: "getTeamsAndMembers",
+		Query:  getTeamsAndMembers_Operation,
+		Variables: &__getTeamsAndMembersInput{
+			TeamsCursor: teamsCursor,
+		},
+	}
+
+	data_ = &getTeamsAndMembersResponse{}
+	resp_ := &graphql.Response{Data: data_}
+
+	err_ = client_.MakeRequest(
+		ctx_,
+		req_,
+		resp_,
+	)
+
+	return data_, err_
+}
+
+// The query executed by preflight.
+const preflight_Operation = `
+query preflight {
+	me {
+		__typename
+		... on User {
+			name
+		}
+		... on ServiceAccount {
+			name
+		}
+	}
+	teams(first: 1) {
+		nodes {
+			slug
+			members(first: 1) {
+				nodes {
+					role
+				}
+			}
+		}
+	}
+}
+`
+
+func preflight(
+	ctx_ context.Context,
+	client_ graphql.Client,
+) (data_ *preflightResponse, err_ error) {
+	req_ := &graphql.Request{
+		OpName: "preflight",
+		Query:  preflight_Operation,
+	}
+
+	data_ = &preflightResponse{}
+	resp_ := &graphql.Response{Data: data_}
+
+	err_ = client_.MakeRequest(
+		ctx_,
+		req_,
+		resp_,
+	)
+
+	return data_, err_
+}
@@ -0,0 +1,162 @@
+package naisapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/nais/slack-teams-notification/internal/httputils"
+	"github.com/sirupsen/logrus"
+)
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors GraphQLErrors   `json:"errors"`
+}
+
+// graphQLClient makes the requests of the operations generated from the queries, see queries.go. The requests are
+// retried according to the retry configuration of the client, and the errors in the responses are handled according to
+// its partial data policy.
+type graphQLClient Client
+
+var _ graphql.Client = (*graphQLClient)(nil)
+
+// graphQL returns the client used to make the requests of the generated operations.
+func (c *Client) graphQL() graphql.Client {
+	return (*graphQLClient)(c)
+}
+
+// MakeRequest sends the request and decodes the data of the response into resp.Data. If the response contains GraphQL
+// errors they are returned, unless the response also contains data and the client is configured to continue with
+// partial data.
+func (g *graphQLClient) MakeRequest(ctx context.Context, req *graphql.Request, resp *graphql.Response) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("encode GraphQL request %q: %w", req.OpName, err)
+	}
+
+	responseBody, err := gqlRequest(
+		ctx,
+		g.endpoint,
+		body,
+		http.Header{
+			"User-Agent":    {httputils.UserAgent},
+			"Content-Type":  {"application/json"},
+			"Authorization": {"Bearer " + g.apiToken},
+		},
+		g.retry,
+		g.log,
+	)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := responseBody.Close(); err != nil {
+			g.log.WithError(err).Errorf("failed to close response body")
+		}
+	}()
+
+	gqlResp := &graphQLResponse{}
+	if err := json.NewDecoder(responseBody).Decode(gqlResp); err != nil {
+		return err
+	}
+
+	hasData := len(gqlResp.Data) > 0 && string(gqlResp.Data) != "null"
+	if len(gqlResp.Errors) > 0 {
+		if !hasData || g.partialDataPolicy != PartialDataWarn {
+			return fmt.Errorf("GraphQL operation %q: %w", req.OpName, gqlResp.Errors)
+		}
+
+		for _, gqlErr := range gqlResp.Errors {
+			g.log.WithFields(logrus.Fields{
+				"operation":  req.OpName,
+				"path":       gqlErr.PathString(),
+				"extensions": gqlErr.Extensions,
+			}).Warnf("partial data from Nais API: %s", gqlErr.Message)
+		}
+	}
+
+	if !hasData {
+		return nil
+	}
+
+	if err := json.Unmarshal(gqlResp.Data, resp.Data); err != nil {
+		return fmt.Errorf("decode data of GraphQL operation %q: %w", req.OpName, err)
+	}
+	return nil
+}
+
+// gqlRequest posts the body to the GraphQL endpoint and returns the body of the response. Transient errors are retried
+// according to the retry configuration.
+func gqlRequest(ctx context.Context, rawURL string, body []byte, headers http.Header, retry RetryConfig, log logrus.FieldLogger) (io.ReadCloser, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	client := http.Client{
+		Timeout: requestTimeout,
+	}
+
+	for attempt := 1; ; attempt++ {
+		responseBody, err := gqlAttempt(ctx, &client, u.String(), body, headers)
+		if err == nil {
+			return responseBody, nil
+		}
+
+		if attempt > retry.MaxRetries || !retryable(err) || ctx.Err() != nil {
+			return nil, err
+		}
+
+		delay := retry.backoff(attempt)
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+			// A single response must not be able to stall the run, so a longer wait than the maximum backoff fails
+			if statusErr.RetryAfter > retry.MaxBackoff {
+				return nil, fmt.Errorf("%w: the server asked to retry in %v, which is longer than the maximum backoff of %v", err, statusErr.RetryAfter, retry.MaxBackoff)
+			}
+			delay = statusErr.RetryAfter
+		}
+
+		log.WithError(err).WithFields(logrus.Fields{
+			"attempt":     attempt,
+			"max_retries": retry.MaxRetries,
+			"retry_in":    delay.String(),
+		}).Warnf("request to Nais API failed, retrying")
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func gqlAttempt(ctx context.Context, client *http.Client, rawURL string, body []byte, headers http.Header) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", rawURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = headers.Clone()
+	// #nosec G704
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+		return nil, &StatusError{
+			StatusCode: res.StatusCode,
+			URL:        rawURL,
+			RetryAfter: retryAfter(res),
+		}
+	}
+	return res.Body, nil
+}
@@ -0,0 +1,77 @@
+package naisapi
+
+import (
+	"iter"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FilterBySlug returns an iterator that only yields the teams included in the filter. If the filter is empty, all
+// teams are yielded. Errors are always passed through.
+func FilterBySlug(teams iter.Seq2[Team, error], teamSlugsFilter []string, log logrus.FieldLogger) iter.Seq2[Team, error] {
+	if len(teamSlugsFilter) == 0 {
+		log.Debugf("no filter specified, return all teams")
+		return teams
+	}
+
+	log.Debugf("filter teams: %q", strings.Join(teamSlugsFilter, ", "))
+	return func(yield func(Team, error) bool) {
+		for team, err := range teams {
+			if err == nil && !slices.Contains(teamSlugsFilter, team.Slug) {
+				continue
+			}
+
+			if !yield(team, err) {
+				return
+			}
+		}
+	}
+}
+
+// SortedBySlug returns an iterator that yields the teams sorted by slug. Since sorting requires all teams, nothing is
+// yielded until the underlying iterator is exhausted. If the underlying iterator yields an error, only the error is
+// yielded.
+func SortedBySlug(teams iter.Seq2[Team, error]) iter.Seq2[Team, error] {
+	return func(yield func(Team, error) bool) {
+		all, err := Collect(teams)
+		if err != nil {
+			yield(Team{}, err)
+			return
+		}
+
+		slices.SortStableFunc(all, func(a, b Team) int {
+			return strings.Compare(a.Slug, b.Slug)
+		})
+
+		for _, team := range all {
+			if !yield(team, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the teams in the slice.
+func Values(teams []Team) iter.Seq2[Team, error] {
+	return func(yield func(Team, error) bool) {
+		for _, team := range teams {
+			if !yield(team, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Collect gathers all teams from the iterator in a slice. The first error stops the iteration and is returned.
+func Collect(teams iter.Seq2[Team, error]) ([]Team, error) {
+	all := make([]Team, 0)
+	for team, err := range teams {
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, team)
+	}
+	return all, nil
+}
@@ -1,20 +1,10 @@
 package naisapi
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
-	"maps"
-	"net/http"
-	"net/url"
-	"slices"
-	"sort"
-	"strings"
+	"iter"
 	"time"
 
-	"github.com/nais/slack-teams-notification/internal/httputils"
 	"github.com/sirupsen/logrus"
 )
 
@@ -22,40 +12,28 @@ const (
 	requestTimeout = time.Second * 10
 )
 
-type PaginatedGraphQLResponse struct {
-	Data struct {
-		Teams struct {
-			PageInfo struct {
-				TotalCount  int    `json:"totalCount"`
-				HasNextPage bool   `json:"hasNextPage"`
-				EndCursor   string `json:"endCursor"`
-			} `json:"pageInfo"`
-			Nodes []struct {
-				Slug         string `json:"slug"`
-				SlackChannel string `json:"slackChannel"`
-				Members      struct {
-					PageInfo struct {
-						TotalCount  int    `json:"totalCount"`
-						HasNextPage bool   `json:"hasNextPage"`
-						EndCursor   string `json:"endCursor"`
-					} `json:"pageInfo"`
-					Nodes []struct {
-						User struct {
-							Name  string `json:"name"`
-							Email string `json:"email"`
-						} `json:"user"`
-						Role string `json:"role"`
-					}
-				} `json:"members"`
-			} `json:"nodes"`
-		} `json:"teams"`
-	} `json:"data"`
+type Team struct {
+	Slug               string
+	SlackChannel       string
+	Purpose            string
+	DeletionInProgress bool
+	Environments       []Environment
+	ExternalResources  ExternalResources
+	Members            []Member
 }
 
-type Team struct {
-	Slug         string
-	SlackChannel string
-	Members      []Member
+// Environment is an environment the team has access to, e.g. dev-gcp or prod-gcp.
+type Environment struct {
+	Name         string
+	GCPProjectID string
+}
+
+// ExternalResources are the resources outside of Nais that membership in the team grants access to. Resources the
+// team does not have are empty.
+type ExternalResources struct {
+	GitHubTeam   string
+	GoogleGroup  string
+	EntraIDGroup string
 }
 
 type Member struct {
@@ -70,165 +48,163 @@ type NaisTeam struct {
 }
 
 type Client struct {
-	endpoint string
-	apiToken string
-	log      logrus.FieldLogger
+	endpoint          string
+	apiToken          string
+	retry             RetryConfig
+	partialDataPolicy PartialDataPolicy
+	log               logrus.FieldLogger
 }
 
-func NewClient(endpoint, apiToken string, log logrus.FieldLogger) *Client {
-	return &Client{
-		endpoint: endpoint,
-		apiToken: apiToken,
-		log:      log,
+type ClientOption func(*Client)
+
+// WithRetryConfig sets the retry configuration used for requests to the Nais API.
+func WithRetryConfig(retry RetryConfig) ClientOption {
+	return func(c *Client) {
+		c.retry = retry
+	}
+}
+
+// WithPartialDataPolicy sets what to do when a response from the Nais API contains both data and errors.
+func WithPartialDataPolicy(policy PartialDataPolicy) ClientOption {
+	return func(c *Client) {
+		c.partialDataPolicy = policy
 	}
 }
 
+func NewClient(endpoint, apiToken string, log logrus.FieldLogger, opts ...ClientOption) *Client {
+	c := &Client{
+		endpoint:          endpoint,
+		apiToken:          apiToken,
+		retry:             DefaultRetryConfig(),
+		partialDataPolicy: PartialDataFail,
+		log:               log,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// GetTeams returns all teams included in the filter, or all teams if the filter is empty, sorted by slug.
 func (c *Client) GetTeams(ctx context.Context, teamSlugsFilter []string) ([]Team, error) {
-	query := `query getTeamsAndMembers {
-  		teams(first:100 after:%q) {
-			pageInfo {
-				totalCount
-				hasNextPage
-				endCursor
-			}
-  			nodes {
-      			slug
-				slackChannel
-      			members(first:100 after:%q) {
-					pageInfo {
-						totalCount
-						hasNextPage
-						endCursor
-					}
-        			nodes {
-						user {
-							name
-							email
-						}
-          				role
-        			}
-      			}
-    		}
-  		}
-	}`
-
-	allTeams := make(map[string]Team)
-	teamsCursor, membersCursor := "", ""
-	teamsHasNextPage := true
-	resp := &PaginatedGraphQLResponse{}
-
-	c.log.Debugf("start fetching teams and members from Nais API")
-	for teamsHasNextPage {
-	fetch:
-		err := func() error {
-			responseBody, err := gqlRequest(
-				ctx,
-				c.endpoint,
-				fmt.Sprintf(`{"query": %q}`, fmt.Sprintf(query, teamsCursor, membersCursor)),
-				http.Header{
-					"User-Agent":    {httputils.UserAgent},
-					"Content-Type":  {"application/json"},
-					"Authorization": {"Bearer " + c.apiToken},
-				},
-			)
+	return Collect(SortedBySlug(FilterBySlug(c.Teams(ctx), teamSlugsFilter, c.log)))
+}
+
+// Teams returns an iterator over all teams in the Nais API, including all of their members. Teams are yielded as the
+// pages arrive, in the order returned by the API. If a request fails, the error is yielded and the iteration stops.
+func (c *Client) Teams(ctx context.Context) iter.Seq2[Team, error] {
+	return func(yield func(Team, error) bool) {
+		teamsCursor := ""
+		teamsHasNextPage := true
+
+		c.log.Debugf("start fetching teams and members from Nais API")
+		for teamsHasNextPage {
+			resp, err := getTeamsAndMembers(ctx, c.graphQL(), cursor(teamsCursor))
 			if err != nil {
-				return err
+				yield(Team{}, err)
+				return
 			}
-			defer func() {
-				if err := responseBody.Close(); err != nil {
-					c.log.WithError(err).Errorf("failed to close response body")
-				}
-			}()
-			return json.NewDecoder(responseBody).Decode(resp)
-		}()
-		if err != nil {
-			return nil, err
-		}
 
-		for _, teamNode := range resp.Data.Teams.Nodes {
-			team, exists := allTeams[teamNode.Slug]
-			if !exists {
-				team = Team{
-					Slug:         teamNode.Slug,
-					SlackChannel: teamNode.SlackChannel,
-					Members:      make([]Member, 0),
+			c.log.WithFields(logrus.Fields{
+				"total_count":   resp.Teams.PageInfo.TotalCount,
+				"has_next_page": resp.Teams.PageInfo.HasNextPage,
+			}).Debugf("fetched page of teams")
+
+			for _, teamNode := range resp.Teams.Nodes {
+				team := toTeam(teamNode)
+
+				if teamNode.Members.PageInfo.HasNextPage {
+					remainingMembers, err := c.getRemainingTeamMembers(ctx, teamNode.Slug, teamNode.Members.PageInfo.EndCursor)
+					if err != nil {
+						yield(Team{}, err)
+						return
+					}
+					team.Members = append(team.Members, remainingMembers...)
 				}
-			}
 
-			for _, memberNode := range teamNode.Members.Nodes {
-				team.Members = append(team.Members, Member{
-					Name:  memberNode.User.Name,
-					Email: memberNode.User.Email,
-					Role:  memberNode.Role,
-				})
+				if !yield(team, nil) {
+					return
+				}
 			}
-			allTeams[teamNode.Slug] = team
 
-			if teamNode.Members.PageInfo.HasNextPage {
-				c.log.WithField("team_slug", teamNode.Slug).Debugf("team has more members, fetching next page")
-				membersCursor = teamNode.Members.PageInfo.EndCursor
-				goto fetch
-			}
+			teamsCursor = resp.Teams.PageInfo.EndCursor
+			teamsHasNextPage = resp.Teams.PageInfo.HasNextPage
 		}
 
-		membersCursor = ""
-		teamsCursor = resp.Data.Teams.PageInfo.EndCursor
-		teamsHasNextPage = resp.Data.Teams.PageInfo.HasNextPage
-
-		c.log.WithFields(logrus.Fields{
-			"total_count":   resp.Data.Teams.PageInfo.TotalCount,
-			"has_next_page": teamsHasNextPage,
-		}).Debugf("fetched page of teams")
+		c.log.Debugf("done fetching Nais teams")
 	}
+}
 
-	c.log.Debugf("done fetching Nais teams")
-
-	if len(teamSlugsFilter) == 0 {
-		c.log.Debugf("no filter specified, return all teams")
-		return slices.SortedStableFunc(maps.Values(allTeams), func(a Team, b Team) int {
-			return strings.Compare(a.Slug, b.Slug)
-		}), nil
-	}
+// getRemainingTeamMembers fetches the members of a single team, starting after the given cursor.
+func (c *Client) getRemainingTeamMembers(ctx context.Context, teamSlug, membersCursor string) ([]Member, error) {
+	log := c.log.WithField("team_slug", teamSlug)
+	members := make([]Member, 0)
+	for {
+		log.Debugf("team has more members, fetching next page")
+		resp, err := getTeamMembers(ctx, c.graphQL(), teamSlug, cursor(membersCursor))
+		if err != nil {
+			return nil, err
+		}
 
-	filteredTeams := make([]Team, 0)
-	c.log.Debugf("filter teams: %q", strings.Join(teamSlugsFilter, ", "))
-	for _, team := range allTeams {
-		for _, includeTeam := range teamSlugsFilter {
-			if team.Slug == includeTeam {
-				filteredTeams = append(filteredTeams, team)
-			}
+		members = append(members, toMembers(resp.Team.Members.Nodes)...)
+		if !resp.Team.Members.PageInfo.HasNextPage {
+			return members, nil
 		}
+		membersCursor = resp.Team.Members.PageInfo.EndCursor
 	}
-	sort.SliceStable(filteredTeams, func(a, b int) bool {
-		return filteredTeams[a].Slug < filteredTeams[b].Slug
-	})
-	return filteredTeams, nil
 }
 
-func (m Member) IsOwner() bool {
-	return m.Role == "OWNER"
-}
+func toTeam(node teamNode) Team {
+	var environments []Environment
+	for _, env := range node.Environments {
+		environments = append(environments, Environment{
+			Name:         env.Environment.Name,
+			GCPProjectID: env.GcpProjectID,
+		})
+	}
 
-func gqlRequest(ctx context.Context, rawURL, body string, headers http.Header) (io.ReadCloser, error) {
-	u, err := url.Parse(rawURL)
-	if err != nil {
-		return nil, err
+	externalResources := ExternalResources{}
+	if r := node.ExternalResources.GitHubTeam; r != nil {
+		externalResources.GitHubTeam = r.Slug
 	}
-	req, err := http.NewRequestWithContext(ctx, "POST", u.String(), bytes.NewBuffer([]byte(body)))
-	if err != nil {
-		return nil, err
+	if r := node.ExternalResources.GoogleGroup; r != nil {
+		externalResources.GoogleGroup = r.Email
 	}
-	req.Header = headers
-	client := http.Client{
-		Timeout: requestTimeout,
+	if r := node.ExternalResources.EntraIDGroup; r != nil {
+		externalResources.EntraIDGroup = r.GroupID
 	}
-	// #nosec G704
-	res, err := client.Do(req)
-	if err != nil {
-		return nil, err
+
+	return Team{
+		Slug:               node.Slug,
+		SlackChannel:       node.SlackChannel,
+		Purpose:            node.Purpose,
+		DeletionInProgress: node.DeletionInProgress,
+		Environments:       environments,
+		ExternalResources:  externalResources,
+		Members:            toMembers(node.Members.Nodes),
 	}
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected HTTP status code %d from %q: %v", res.StatusCode, rawURL, res)
+}
+
+func toMembers(nodes []teamMemberNode) []Member {
+	members := make([]Member, 0, len(nodes))
+	for _, node := range nodes {
+		members = append(members, Member{
+			Name:  node.User.Name,
+			Email: node.User.Email,
+			Role:  string(node.Role),
+		})
 	}
-	return res.Body, nil
+	return members
+}
+
+// IsBeingDeleted reports whether the team is being deleted, in which case its owners should no longer be asked to keep
+// it up to date.
+func (t Team) IsBeingDeleted() bool {
+	return t.DeletionInProgress
+}
+
+func (m Member) IsOwner() bool {
+	return m.Role == "OWNER"
 }
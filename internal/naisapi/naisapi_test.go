@@ -2,12 +2,17 @@ package naisapi_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/sirupsen/logrus"
 	logrustest "github.com/sirupsen/logrus/hooks/test"
 )
 
@@ -184,6 +189,234 @@ func TestGetTeams(t *testing.T) {
 		}
 	})
 
+	t.Run("team metadata in response", func(t *testing.T) {
+		ts := httpServerWithHandlers(t, []http.HandlerFunc{
+			func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{
+					"data": {
+						"teams": {
+							"pageInfo": {"totalCount": 2, "hasNextPage": false, "endCursor": ""},
+							"nodes": [
+								{
+									"slug": "team1",
+									"slackChannel": "#team1",
+									"purpose": "Some purpose",
+									"deletionInProgress": true,
+									"environments": [
+										{"environment": {"name": "dev"}, "gcpProjectID": "team1-dev-abcd"},
+										{"environment": {"name": "prod"}, "gcpProjectID": "team1-prod-efgh"}
+									],
+									"externalResources": {
+										"gitHubTeam": {"slug": "team1"},
+										"googleGroup": {"email": "team1@example.com"},
+										"entraIDGroup": null
+									},
+									"members": {"pageInfo": {"hasNextPage": false}, "nodes": []}
+								},
+								{
+									"slug": "team2",
+									"environments": [],
+									"externalResources": {"gitHubTeam": null, "googleGroup": null, "entraIDGroup": null},
+									"members": {"pageInfo": {"hasNextPage": false}, "nodes": []}
+								}
+							]
+						}
+					}
+				}`))
+			},
+		})
+		defer ts.Close()
+
+		teamsClient := naisapi.NewClient(ts.URL, apiToken, log)
+		naisTeams, err := teamsClient.GetTeams(ctx, emptyTeamSlugsFilter)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if len(naisTeams) != 2 {
+			t.Fatalf("expected 2 teams, got: %v", naisTeams)
+		}
+
+		team1 := naisTeams[0]
+		if team1.Purpose != "Some purpose" {
+			t.Errorf("unexpected purpose: %q", team1.Purpose)
+		}
+
+		if !team1.DeletionInProgress {
+			t.Errorf("expected deletion to be in progress")
+		}
+
+		if len(team1.Environments) != 2 || team1.Environments[1].Name != "prod" || team1.Environments[1].GCPProjectID != "team1-prod-efgh" {
+			t.Errorf("unexpected environments: %+v", team1.Environments)
+		}
+
+		expectedResources := naisapi.ExternalResources{GitHubTeam: "team1", GoogleGroup: "team1@example.com"}
+		if team1.ExternalResources != expectedResources {
+			t.Errorf("unexpected external resources: %+v", team1.ExternalResources)
+		}
+
+		if naisTeams[1].DeletionInProgress || naisTeams[1].ExternalResources != (naisapi.ExternalResources{}) {
+			t.Errorf("unexpected metadata for team without resources: %+v", naisTeams[1])
+		}
+	})
+
+	t.Run("request contains operation name and variables", func(t *testing.T) {
+		ts := httpServerWithHandlers(t, []http.HandlerFunc{
+			func(w http.ResponseWriter, r *http.Request) {
+				req := struct {
+					OperationName string         `json:"operationName"`
+					Query         string         `json:"query"`
+					Variables     map[string]any `json:"variables"`
+				}{}
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Fatalf("unable to decode request: %v", err)
+				}
+
+				if req.OperationName != "getTeamsAndMembers" {
+					t.Errorf("unexpected operation name: %q", req.OperationName)
+				}
+
+				if !strings.HasPrefix(strings.TrimSpace(req.Query), "query getTeamsAndMembers ") {
+					t.Errorf("unexpected query: %q", req.Query)
+				}
+
+				if v, ok := req.Variables["teamsCursor"]; !ok || v != nil {
+					t.Errorf("expected null teamsCursor variable, got: %v", req.Variables)
+				}
+
+				_, _ = w.Write([]byte(`{"data": {"teams": {"pageInfo": {"hasNextPage": true, "endCursor": "next"}, "nodes": []}}}`))
+			},
+			func(w http.ResponseWriter, r *http.Request) {
+				req := struct {
+					Variables map[string]any `json:"variables"`
+				}{}
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Fatalf("unable to decode request: %v", err)
+				}
+
+				if req.Variables["teamsCursor"] != "next" {
+					t.Errorf("expected teamsCursor variable to be %q, got: %v", "next", req.Variables)
+				}
+
+				_, _ = w.Write([]byte(`{"data": {"teams": {"pageInfo": {"hasNextPage": false}, "nodes": []}}}`))
+			},
+		})
+		defer ts.Close()
+
+		teamsClient := naisapi.NewClient(ts.URL, apiToken, log)
+		if _, err := teamsClient.GetTeams(ctx, emptyTeamSlugsFilter); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("several teams with more members than fit on a single page", func(t *testing.T) {
+		expectMembersRequest := func(t *testing.T, r *http.Request, slug, membersCursor string) {
+			t.Helper()
+			req := struct {
+				OperationName string         `json:"operationName"`
+				Variables     map[string]any `json:"variables"`
+			}{}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("unable to decode request: %v", err)
+			}
+
+			if req.OperationName != "getTeamMembers" {
+				t.Errorf("expected getTeamMembers operation, got: %q", req.OperationName)
+			}
+
+			if req.Variables["slug"] != slug {
+				t.Errorf("expected slug variable %q, got: %v", slug, req.Variables["slug"])
+			}
+
+			if req.Variables["membersCursor"] != membersCursor {
+				t.Errorf("expected membersCursor variable %q, got: %v", membersCursor, req.Variables["membersCursor"])
+			}
+		}
+
+		ts := httpServerWithHandlers(t, []http.HandlerFunc{
+			func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{
+					"data": {
+						"teams": {
+							"pageInfo": {"totalCount": 3, "hasNextPage": false, "endCursor": ""},
+							"nodes": [
+								{
+									"slug": "team1",
+									"members": {
+										"pageInfo": {"totalCount": 5, "hasNextPage": true, "endCursor": "team1-cursor1"},
+										"nodes": [` + membersJSON("team1", 0, 2) + `]
+									}
+								},
+								{
+									"slug": "team2",
+									"members": {
+										"pageInfo": {"totalCount": 1, "hasNextPage": false, "endCursor": ""},
+										"nodes": [` + membersJSON("team2", 0, 1) + `]
+									}
+								},
+								{
+									"slug": "team3",
+									"members": {
+										"pageInfo": {"totalCount": 3, "hasNextPage": true, "endCursor": "team3-cursor1"},
+										"nodes": [` + membersJSON("team3", 0, 2) + `]
+									}
+								}
+							]
+						}
+					}
+				}`))
+			},
+			func(w http.ResponseWriter, r *http.Request) {
+				expectMembersRequest(t, r, "team1", "team1-cursor1")
+				_, _ = w.Write([]byte(`{"data": {"team": {"members": {
+					"pageInfo": {"totalCount": 5, "hasNextPage": true, "endCursor": "team1-cursor2"},
+					"nodes": [` + membersJSON("team1", 2, 4) + `]
+				}}}}`))
+			},
+			func(w http.ResponseWriter, r *http.Request) {
+				expectMembersRequest(t, r, "team1", "team1-cursor2")
+				_, _ = w.Write([]byte(`{"data": {"team": {"members": {
+					"pageInfo": {"totalCount": 5, "hasNextPage": false, "endCursor": ""},
+					"nodes": [` + membersJSON("team1", 4, 5) + `]
+				}}}}`))
+			},
+			func(w http.ResponseWriter, r *http.Request) {
+				expectMembersRequest(t, r, "team3", "team3-cursor1")
+				_, _ = w.Write([]byte(`{"data": {"team": {"members": {
+					"pageInfo": {"totalCount": 3, "hasNextPage": false, "endCursor": ""},
+					"nodes": [` + membersJSON("team3", 2, 3) + `]
+				}}}}`))
+			},
+		})
+		defer ts.Close()
+
+		teamsClient := naisapi.NewClient(ts.URL, apiToken, log)
+		naisTeams, err := teamsClient.GetTeams(ctx, emptyTeamSlugsFilter)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if len(naisTeams) != 3 {
+			t.Fatalf("expected 3 teams, got: %v", naisTeams)
+		}
+
+		expectedMembers := map[string]int{"team1": 5, "team2": 1, "team3": 3}
+		for _, team := range naisTeams {
+			if len(team.Members) != expectedMembers[team.Slug] {
+				t.Errorf("expected %d members in %q, got: %v", expectedMembers[team.Slug], team.Slug, team.Members)
+			}
+
+			seen := make(map[string]bool)
+			for i, member := range team.Members {
+				expectedEmail := fmt.Sprintf("%s.user%d@example.com", team.Slug, i)
+				if member.Email != expectedEmail {
+					t.Errorf("expected member %d of %q to be %q, got: %q", i, team.Slug, expectedEmail, member.Email)
+				}
+
+				if seen[member.Email] {
+					t.Errorf("duplicate member %q in %q", member.Email, team.Slug)
+				}
+				seen[member.Email] = true
+			}
+		}
+	})
+
 	t.Run("team slugs filter is not empty", func(t *testing.T) {
 		ts := httpServerWithHandlers(t, []http.HandlerFunc{
 			func(w http.ResponseWriter, r *http.Request) {
@@ -268,6 +501,315 @@ func TestGetTeams(t *testing.T) {
 	})
 }
 
+func TestTeams(t *testing.T) {
+	ctx := context.Background()
+	const apiToken = "some secret token"
+	log, _ := logrustest.NewNullLogger()
+
+	t.Run("teams are yielded before the next page is fetched", func(t *testing.T) {
+		ts := httpServerWithHandlers(t, []http.HandlerFunc{
+			func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"data": {"teams": {
+					"pageInfo": {"totalCount": 3, "hasNextPage": true, "endCursor": "next"},
+					"nodes": [{"slug": "team1"}, {"slug": "team2"}]
+				}}}`))
+			},
+		})
+		defer ts.Close()
+
+		slugs := make([]string, 0)
+		for team, err := range naisapi.NewClient(ts.URL, apiToken, log).Teams(ctx) {
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			slugs = append(slugs, team.Slug)
+			if len(slugs) == 2 {
+				break
+			}
+		}
+
+		if strings.Join(slugs, ",") != "team1,team2" {
+			t.Errorf("unexpected teams: %v", slugs)
+		}
+	})
+
+	t.Run("error is yielded", func(t *testing.T) {
+		ts := httpServerWithHandlers(t, []http.HandlerFunc{
+			func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"data": {"teams": {
+					"pageInfo": {"totalCount": 2, "hasNextPage": true, "endCursor": "next"},
+					"nodes": [{"slug": "team1"}]
+				}}}`))
+			},
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+			},
+		})
+		defer ts.Close()
+
+		teams, errs := 0, 0
+		for _, err := range naisapi.NewClient(ts.URL, apiToken, log).Teams(ctx) {
+			if err != nil {
+				errs++
+			} else {
+				teams++
+			}
+		}
+
+		if teams != 1 || errs != 1 {
+			t.Errorf("expected 1 team and 1 error, got %d teams and %d errors", teams, errs)
+		}
+	})
+
+	t.Run("adapters", func(t *testing.T) {
+		teams := naisapi.Values([]naisapi.Team{{Slug: "team3"}, {Slug: "team1"}, {Slug: "team2"}})
+
+		sorted, err := naisapi.Collect(naisapi.SortedBySlug(naisapi.FilterBySlug(teams, []string{"team3", "team1"}, log)))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if len(sorted) != 2 || sorted[0].Slug != "team1" || sorted[1].Slug != "team3" {
+			t.Errorf("unexpected teams: %v", sorted)
+		}
+	})
+}
+
+func TestGetTeams_Retries(t *testing.T) {
+	ctx := context.Background()
+	const apiToken = "some secret token"
+	log, hook := logrustest.NewNullLogger()
+	retryConfig := naisapi.RetryConfig{
+		MaxRetries:     2,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond * 10,
+	}
+	emptyResponse := `{"data": {"teams": {"pageInfo": {"hasNextPage": false}, "nodes": [{"slug": "team1"}]}}}`
+
+	t.Run("transient errors are retried", func(t *testing.T) {
+		hook.Reset()
+		ts := httpServerWithHandlers(t, []http.HandlerFunc{
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+			},
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(emptyResponse))
+			},
+		})
+		defer ts.Close()
+
+		teamsClient := naisapi.NewClient(ts.URL, apiToken, log, naisapi.WithRetryConfig(retryConfig))
+		naisTeams, err := teamsClient.GetTeams(ctx, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if len(naisTeams) != 1 {
+			t.Fatalf("expected 1 team, got: %v", naisTeams)
+		}
+
+		if len(hook.AllEntries()) != 2 {
+			t.Fatalf("expected 2 log entries, got: %d", len(hook.AllEntries()))
+		}
+
+		for i, entry := range hook.AllEntries() {
+			if entry.Data["attempt"] != i+1 {
+				t.Errorf("expected attempt %d to be logged, got: %v", i+1, entry.Data["attempt"])
+			}
+		}
+	})
+
+	t.Run("give up after max retries", func(t *testing.T) {
+		ts := httpServerWithHandlers(t, []http.HandlerFunc{
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+		})
+		defer ts.Close()
+
+		teamsClient := naisapi.NewClient(ts.URL, apiToken, log, naisapi.WithRetryConfig(retryConfig))
+		_, err := teamsClient.GetTeams(ctx, nil)
+
+		var statusErr *naisapi.StatusError
+		if !errors.As(err, &statusErr) {
+			t.Fatalf("expected status error, got: %v", err)
+		} else if statusErr.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("unexpected status code: %d", statusErr.StatusCode)
+		}
+	})
+
+	t.Run("client errors are not retried", func(t *testing.T) {
+		ts := httpServerWithHandlers(t, []http.HandlerFunc{
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			},
+		})
+		defer ts.Close()
+
+		teamsClient := naisapi.NewClient(ts.URL, apiToken, log, naisapi.WithRetryConfig(retryConfig))
+		if _, err := teamsClient.GetTeams(ctx, nil); err == nil {
+			t.Fatalf("expected error, got nil")
+		}
+	})
+
+	t.Run("retry after header is honored", func(t *testing.T) {
+		ts := httpServerWithHandlers(t, []http.HandlerFunc{
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(emptyResponse))
+			},
+		})
+		defer ts.Close()
+
+		start := time.Now()
+		retryConfig := retryConfig
+		retryConfig.MaxBackoff = 2 * time.Second
+		teamsClient := naisapi.NewClient(ts.URL, apiToken, log, naisapi.WithRetryConfig(retryConfig))
+		if _, err := teamsClient.GetTeams(ctx, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if elapsed := time.Since(start); elapsed < time.Second {
+			t.Errorf("expected to wait at least 1s before retrying, waited %v", elapsed)
+		}
+	})
+
+	t.Run("retry after longer than max backoff fails", func(t *testing.T) {
+		ts := httpServerWithHandlers(t, []http.HandlerFunc{
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "3600")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+		})
+		defer ts.Close()
+
+		start := time.Now()
+		teamsClient := naisapi.NewClient(ts.URL, apiToken, log, naisapi.WithRetryConfig(retryConfig))
+		_, err := teamsClient.GetTeams(ctx, nil)
+
+		var statusErr *naisapi.StatusError
+		if !errors.As(err, &statusErr) || statusErr.RetryAfter != time.Hour {
+			t.Fatalf("expected status error with the requested delay, got: %v", err)
+		}
+
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("expected to fail without waiting, waited %v", elapsed)
+		}
+	})
+}
+
+func TestGetTeams_GraphQLErrors(t *testing.T) {
+	ctx := context.Background()
+	const apiToken = "some secret token"
+	log, hook := logrustest.NewNullLogger()
+	partialResponse := `{
+		"errors": [
+			{
+				"message": "something went wrong",
+				"path": ["teams", "nodes", 1, "members"],
+				"extensions": {"code": "INTERNAL"}
+			}
+		],
+		"data": {
+			"teams": {
+				"pageInfo": {"hasNextPage": false},
+				"nodes": [{"slug": "team1"}, {"slug": "team2"}]
+			}
+		}
+	}`
+
+	t.Run("errors without data", func(t *testing.T) {
+		ts := httpServerWithHandlers(t, []http.HandlerFunc{
+			func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"errors": [{"message": "unauthorized", "extensions": {"code": "UNAUTHENTICATED"}}], "data": null}`))
+			},
+		})
+		defer ts.Close()
+
+		teamsClient := naisapi.NewClient(ts.URL, apiToken, log, naisapi.WithPartialDataPolicy(naisapi.PartialDataWarn))
+		naisTeams, err := teamsClient.GetTeams(ctx, nil)
+		if naisTeams != nil {
+			t.Errorf("expected nil teams, got: %v", naisTeams)
+		}
+
+		var gqlErr naisapi.GraphQLError
+		if !errors.As(err, &gqlErr) {
+			t.Fatalf("expected GraphQL error, got: %v", err)
+		} else if gqlErr.Message != "unauthorized" {
+			t.Errorf("unexpected message: %q", gqlErr.Message)
+		} else if gqlErr.Extensions["code"] != "UNAUTHENTICATED" {
+			t.Errorf("unexpected extensions: %v", gqlErr.Extensions)
+		}
+	})
+
+	t.Run("partial data fails by default", func(t *testing.T) {
+		ts := httpServerWithHandlers(t, []http.HandlerFunc{
+			func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(partialResponse))
+			},
+		})
+		defer ts.Close()
+
+		teamsClient := naisapi.NewClient(ts.URL, apiToken, log)
+		_, err := teamsClient.GetTeams(ctx, nil)
+
+		var gqlErrs naisapi.GraphQLErrors
+		if !errors.As(err, &gqlErrs) {
+			t.Fatalf("expected GraphQL errors, got: %v", err)
+		} else if len(gqlErrs) != 1 {
+			t.Fatalf("expected 1 error, got: %v", gqlErrs)
+		} else if path := gqlErrs[0].PathString(); path != "teams.nodes.1.members" {
+			t.Errorf("unexpected path: %q", path)
+		}
+	})
+
+	t.Run("partial data with warnings", func(t *testing.T) {
+		hook.Reset()
+		ts := httpServerWithHandlers(t, []http.HandlerFunc{
+			func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(partialResponse))
+			},
+		})
+		defer ts.Close()
+
+		teamsClient := naisapi.NewClient(ts.URL, apiToken, log, naisapi.WithPartialDataPolicy(naisapi.PartialDataWarn))
+		naisTeams, err := teamsClient.GetTeams(ctx, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if len(naisTeams) != 2 {
+			t.Fatalf("expected 2 teams, got: %v", naisTeams)
+		}
+
+		entry := hook.LastEntry()
+		if entry == nil {
+			t.Fatalf("expected warning to be logged")
+		} else if entry.Level != logrus.WarnLevel {
+			t.Errorf("expected warning, got: %v", entry.Level)
+		} else if entry.Data["path"] != "teams.nodes.1.members" {
+			t.Errorf("unexpected path: %v", entry.Data["path"])
+		}
+	})
+}
+
+func TestTeam_IsBeingDeleted(t *testing.T) {
+	if (naisapi.Team{}).IsBeingDeleted() {
+		t.Errorf("team should not be being deleted")
+	}
+
+	if !(naisapi.Team{DeletionInProgress: true}).IsBeingDeleted() {
+		t.Errorf("team should be being deleted")
+	}
+}
+
 func TestMember_IsOwner(t *testing.T) {
 	member := naisapi.Member{Role: "MEMBER"}
 	if member.IsOwner() != false {
@@ -295,3 +837,114 @@ func httpServerWithHandlers(t *testing.T, handlers []http.HandlerFunc) *httptest
 		idx += 1
 	}))
 }
+
+// membersJSON returns a comma separated list of team member nodes with the indexes in [from, to).
+func membersJSON(teamSlug string, from, to int) string {
+	nodes := make([]string, 0)
+	for i := from; i < to; i++ {
+		nodes = append(nodes, fmt.Sprintf(
+			`{"user": {"name": "User %d", "email": "%s.user%d@example.com"}, "role": "MEMBER"}`,
+			i, teamSlug, i,
+		))
+	}
+	return strings.Join(nodes, ",")
+}
+
+func TestPreflight(t *testing.T) {
+	ctx := context.Background()
+	log, _ := logrustest.NewNullLogger()
+	noRetries := naisapi.WithRetryConfig(naisapi.RetryConfig{})
+
+	tests := map[string]struct {
+		handler        http.HandlerFunc
+		expectedReason string
+	}{
+		"valid token": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"data": {"me": {"__typename": "ServiceAccount", "name": "slack-teams-notification"}, "teams": {"nodes": []}}}`))
+			},
+		},
+		"rejected token": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			},
+			expectedReason: "the token was rejected",
+		},
+		"wrong endpoint": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			expectedReason: "there is no GraphQL API at",
+		},
+		"not GraphQL": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`<html>Console</html>`))
+			},
+			expectedReason: "is not GraphQL",
+		},
+		"no permission to list members": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{
+					"errors": [{"message": "not allowed", "path": ["teams", "nodes", 0, "members"]}],
+					"data": {"me": {"__typename": "ServiceAccount", "name": "sa"}, "teams": {"nodes": [{"slug": "team1", "members": null}]}}
+				}`))
+			},
+			expectedReason: "does not have permission to list teams and their members",
+		},
+		"unknown token": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"data": {"me": null, "teams": {"nodes": []}}}`))
+			},
+			expectedReason: "did not recognize the token",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ts := httpServerWithHandlers(t, []http.HandlerFunc{tc.handler})
+			defer ts.Close()
+
+			client := naisapi.NewClient(ts.URL, "token", log, noRetries, naisapi.WithPartialDataPolicy(naisapi.PartialDataWarn))
+			identity, err := client.Preflight(ctx)
+			if tc.expectedReason == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if identity.Kind != "ServiceAccount" || identity.Name != "slack-teams-notification" {
+					t.Errorf("unexpected identity: %+v", identity)
+				}
+				return
+			}
+
+			if !errors.Is(err, naisapi.ErrPreflight) || !strings.Contains(err.Error(), tc.expectedReason) {
+				t.Fatalf("expected preflight error containing %q, got: %v", tc.expectedReason, err)
+			}
+		})
+	}
+
+	t.Run("not retried", func(t *testing.T) {
+		ts := httpServerWithHandlers(t, []http.HandlerFunc{
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+		})
+		defer ts.Close()
+
+		retries := naisapi.WithRetryConfig(naisapi.RetryConfig{MaxRetries: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+		_, err := naisapi.NewClient(ts.URL, "token", log, retries).Preflight(ctx)
+		if !errors.Is(err, naisapi.ErrPreflight) || !strings.Contains(err.Error(), "not available") {
+			t.Fatalf("expected preflight error about the availability, got: %v", err)
+		}
+	})
+
+	t.Run("unreachable endpoint", func(t *testing.T) {
+		ts := httptest.NewServer(http.NotFoundHandler())
+		ts.Close()
+
+		_, err := naisapi.NewClient(ts.URL, "token", log, noRetries).Preflight(ctx)
+		if !errors.Is(err, naisapi.ErrPreflight) || !strings.Contains(err.Error(), "unable to reach") {
+			t.Fatalf("expected preflight error about the endpoint, got: %v", err)
+		}
+	})
+}
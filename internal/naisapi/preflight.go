@@ -0,0 +1,83 @@
+package naisapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// ErrPreflight is returned when the preflight check finds that the Nais API cannot be used with the configured endpoint
+// and token.
+var ErrPreflight = errors.New("preflight check of the Nais API failed")
+
+// Identity is who the Nais API token belongs to.
+type Identity struct {
+	// Kind is the kind of identity, e.g. "User" or "ServiceAccount".
+	Kind string
+	Name string
+}
+
+// Preflight makes a single cheap request to the Nais API to check that the endpoint is reachable, that the token is
+// valid, and that it has permission to list teams and their members. The returned error wraps ErrPreflight and
+// explains which of these failed.
+func (c *Client) Preflight(ctx context.Context) (Identity, error) {
+	// Partial data is never good enough here, since an error on the members is exactly what the check looks for. The
+	// check is not retried either, so a misconfiguration is reported right away instead of after every backoff.
+	strict := *c
+	strict.partialDataPolicy = PartialDataFail
+	strict.retry.MaxRetries = 0
+
+	resp, err := preflight(ctx, strict.graphQL())
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: %s: %w", ErrPreflight, preflightReason(err, c.endpoint), err)
+	}
+
+	switch me := resp.Me.(type) {
+	case *preflightMeUser:
+		return Identity{Kind: me.Typename, Name: me.Name}, nil
+	case *preflightMeServiceAccount:
+		return Identity{Kind: me.Typename, Name: me.Name}, nil
+	default:
+		return Identity{}, fmt.Errorf("%w: the Nais API did not recognize the token", ErrPreflight)
+	}
+}
+
+// preflightReason explains the error from the preflight request in terms of what needs to be fixed.
+func preflightReason(err error, endpoint string) string {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return "the token was rejected, it is probably invalid or expired"
+		case http.StatusNotFound, http.StatusMethodNotAllowed:
+			return fmt.Sprintf("there is no GraphQL API at %q, check the endpoint", endpoint)
+		default:
+			return "the Nais API is not available"
+		}
+	}
+
+	var gqlErrs GraphQLErrors
+	if errors.As(err, &gqlErrs) {
+		for _, gqlErr := range gqlErrs {
+			if gqlErr.Extensions["code"] == "UNAUTHENTICATED" {
+				return "the token was rejected, it is probably invalid or expired"
+			}
+		}
+		return "the token does not have permission to list teams and their members"
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return fmt.Sprintf("the response from %q is not GraphQL, check the endpoint", endpoint)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return fmt.Sprintf("unable to reach %q", endpoint)
+	}
+
+	return "unexpected error"
+}
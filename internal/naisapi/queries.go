@@ -0,0 +1,13 @@
+package naisapi
+
+// The operations and their response types in generated.go are generated from the queries in the queries directory,
+// and are checked against the Nais API schema in schema.graphql. Run go generate after changing a query.
+//go:generate go tool github.com/Khan/genqlient
+
+// cursor returns the value to use for a Cursor variable, where an empty cursor means the first page.
+func cursor(c string) *string {
+	if c == "" {
+		return nil
+	}
+	return &c
+}
@@ -0,0 +1,116 @@
+package naisapi
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultMaxRetries     = 3
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = time.Second * 30
+)
+
+// RetryConfig controls how requests to the Nais API are retried when they fail with a transient error.
+type RetryConfig struct {
+	// MaxRetries is the maximum number of retries after the initial attempt. 0 disables retries.
+	MaxRetries int
+
+	// InitialBackoff is the delay before the first retry. The delay is doubled for each subsequent retry.
+	InitialBackoff time.Duration
+
+	// MaxBackoff is the upper limit of the delay between two attempts. The request fails without being retried if the
+	// server asks for a longer delay through Retry-After.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryConfig returns the retry configuration used when none is given to the client.
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxRetries:     defaultMaxRetries,
+		InitialBackoff: defaultInitialBackoff,
+		MaxBackoff:     defaultMaxBackoff,
+	}
+}
+
+// backoff returns the delay before the given retry, where the first retry is 1. Full jitter is applied to the
+// exponential delay to avoid retries from several clients lining up.
+func (r RetryConfig) backoff(retry int) time.Duration {
+	delay := r.InitialBackoff
+	for i := 1; i < retry && delay < r.MaxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, r.MaxBackoff)
+	if delay <= 0 {
+		return 0
+	}
+
+	// #nosec G404 -- jitter does not need a cryptographically secure source
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// StatusError is returned when the Nais API responds with an unexpected HTTP status code.
+type StatusError struct {
+	StatusCode int
+	URL        string
+
+	// RetryAfter is the delay requested by the server through the Retry-After header, if any.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected HTTP status code %d from %q", e.StatusCode, e.URL)
+}
+
+// retryable reports whether the request that failed with err should be attempted again.
+func retryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	// A host that does not exist is a configuration error, which does not go away by waiting
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return false
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// retryAfter returns the delay requested by the Retry-After header of the response. Only 429 and 503 responses are
+// considered, and both the delay-seconds and HTTP-date formats are supported.
+func retryAfter(res *http.Response) time.Duration {
+	if res.StatusCode != http.StatusTooManyRequests && res.StatusCode != http.StatusServiceUnavailable {
+		return 0
+	}
+
+	value := res.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+
+	return 0
+}
@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/nais/slack-teams-notification/internal/review"
+)
+
+// dryRunReview is a review that would have been delivered, written as a single line of JSON by the dry-run notifier.
+type dryRunReview struct {
+	Channel Channel `json:"channel"`
+	Team    string  `json:"team"`
+	Subject string  `json:"subject"`
+	Text    string  `json:"text"`
+}
+
+// DryRun writes the reviews to a writer instead of delivering them, so the reminders of the other channels can be
+// reviewed alongside the Slack messages in a dry run.
+type DryRun struct {
+	channel Channel
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+var _ Notifier = (*DryRun)(nil)
+
+// NewDryRun returns a notifier that writes the reviews it would have delivered through channel to w.
+func NewDryRun(channel Channel, w io.Writer) *DryRun {
+	return &DryRun{
+		channel: channel,
+		w:       w,
+	}
+}
+
+func (d *DryRun) Notify(_ context.Context, team naisapi.Team, r review.Review) error {
+	data, err := json.Marshal(dryRunReview{
+		Channel: d.channel,
+		Team:    team.Slug,
+		Subject: r.Subject,
+		Text:    r.Text(),
+	})
+	if err != nil {
+		return fmt.Errorf("encode review: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err = fmt.Fprintf(d.w, "%s\n", data)
+	return err
+}
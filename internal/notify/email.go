@@ -0,0 +1,88 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/nais/slack-teams-notification/internal/review"
+)
+
+// SMTPConfig is the mail server the email channel sends through.
+type SMTPConfig struct {
+	Host string
+	Port int
+
+	// Username and Password are used to authenticate with the server. No authentication is done if Username is empty.
+	Username string
+	Password string
+
+	// From is the sender address of the emails.
+	From string
+}
+
+// Email sends the review reminders as plain text emails.
+type Email struct {
+	config      SMTPConfig
+	preferences *Preferences
+}
+
+var _ Notifier = (*Email)(nil)
+
+// NewEmail returns a notifier that sends the reminders through the mail server in config, to the recipients in the
+// preference of each team.
+func NewEmail(config SMTPConfig, preferences *Preferences) *Email {
+	return &Email{
+		config:      config,
+		preferences: preferences,
+	}
+}
+
+func (e *Email) Notify(ctx context.Context, team naisapi.Team, r review.Review) error {
+	recipients := e.preferences.For(team.Slug).Recipients
+	if len(recipients) == 0 {
+		recipients = ownerEmails(team)
+	}
+
+	if len(recipients) == 0 {
+		return fmt.Errorf("no email recipients for team %q", team.Slug)
+	}
+
+	// net/smtp does not take a context, so a cancelled run is at least not continued with another email
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if e.config.Username != "" {
+		auth = smtp.PlainAuth("", e.config.Username, e.config.Password, e.config.Host)
+	}
+
+	addr := net.JoinHostPort(e.config.Host, strconv.Itoa(e.config.Port))
+	if err := smtp.SendMail(addr, auth, e.config.From, recipients, e.message(recipients, r)); err != nil {
+		return fmt.Errorf("send email: %w", err)
+	}
+	return nil
+}
+
+// message returns the review as an email message, with the headers required by RFC 5322.
+func (e *Email) message(recipients []string, r review.Review) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", e.config.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(recipients, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", r.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(r.Text(), "\n", "\r\n"))
+	return b.Bytes()
+}
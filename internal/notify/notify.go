@@ -0,0 +1,35 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/nais/slack-teams-notification/internal/review"
+)
+
+// Channel is a way of delivering the review reminder to a team.
+type Channel string
+
+const (
+	ChannelSlack   Channel = "slack"
+	ChannelEmail   Channel = "email"
+	ChannelWebhook Channel = "webhook"
+)
+
+// Notifier delivers the review reminder of a team through a single channel.
+type Notifier interface {
+	// Notify delivers the review to the team. A notifier that checks the ledger itself returns an error wrapping
+	// ledger.ErrAlreadyDelivered if the team has already been reminded in the period.
+	Notify(ctx context.Context, team naisapi.Team, review review.Review) error
+}
+
+// ownerEmails returns the email addresses of the owners of the team.
+func ownerEmails(team naisapi.Team) []string {
+	emails := make([]string, 0)
+	for _, member := range team.Members {
+		if member.IsOwner() && member.Email != "" {
+			emails = append(emails, member.Email)
+		}
+	}
+	return emails
+}
@@ -0,0 +1,423 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nais/slack-teams-notification/internal/ledger"
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/nais/slack-teams-notification/internal/notify"
+	"github.com/nais/slack-teams-notification/internal/review"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+)
+
+func writePreferences(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "preferences.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return path
+}
+
+func team(slug string) naisapi.Team {
+	return naisapi.Team{
+		Slug: slug,
+		Members: []naisapi.Member{
+			{Name: "Owner", Email: "owner@example.com", Role: "OWNER"},
+			{Name: "Member", Email: "member@example.com", Role: "MEMBER"},
+		},
+	}
+}
+
+func TestLoadPreferences(t *testing.T) {
+	t.Run("preferences", func(t *testing.T) {
+		preferences, err := notify.LoadPreferences(writePreferences(t, `
+teams:
+  - slug: team-email
+    channel: email
+    recipients: [team@example.com]
+  - slug: team-webhook
+    channel: webhook
+    url: https://example.com/hook
+`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if p := preferences.For("team-email"); p.Channel != notify.ChannelEmail || !slices.Equal(p.Recipients, []string{"team@example.com"}) {
+			t.Errorf("unexpected preference: %+v", p)
+		}
+
+		if p := preferences.For("team-webhook"); p.Channel != notify.ChannelWebhook || p.URL != "https://example.com/hook" {
+			t.Errorf("unexpected preference: %+v", p)
+		}
+
+		if p := preferences.For("other-team"); p.Channel != notify.ChannelSlack {
+			t.Errorf("expected teams without a preference to prefer Slack, got: %+v", p)
+		}
+	})
+
+	t.Run("invalid preferences", func(t *testing.T) {
+		for name, content := range map[string]string{
+			"unsupported channel": "teams:\n  - slug: team1\n    channel: pigeon\n",
+			"missing slug":        "teams:\n  - channel: email\n",
+			"duplicate team":      "teams:\n  - slug: team1\n    channel: email\n  - slug: team1\n    channel: webhook\n",
+			"invalid webhook URL": "teams:\n  - slug: team1\n    channel: webhook\n    url: example.com/hook\n",
+		} {
+			if _, err := notify.LoadPreferences(writePreferences(t, content)); err == nil {
+				t.Errorf("%s: expected error", name)
+			}
+		}
+	})
+
+	t.Run("nil preferences", func(t *testing.T) {
+		var preferences *notify.Preferences
+		if p := preferences.For("team1"); p.Channel != notify.ChannelSlack {
+			t.Errorf("expected Slack, got: %+v", p)
+		}
+	})
+}
+
+// fakeNotifier records the teams it is asked to notify, fails for the teams in fail, and reports the teams in
+// delivered as already notified.
+type fakeNotifier struct {
+	notified  []string
+	fail      []string
+	delivered []string
+}
+
+func (f *fakeNotifier) Notify(_ context.Context, team naisapi.Team, _ review.Review) error {
+	if slices.Contains(f.fail, team.Slug) {
+		return errors.New("delivery failed")
+	} else if slices.Contains(f.delivered, team.Slug) {
+		return ledger.ErrAlreadyDelivered
+	}
+	f.notified = append(f.notified, team.Slug)
+	return nil
+}
+
+func TestRouter(t *testing.T) {
+	ctx := context.Background()
+	log, _ := logrustest.NewNullLogger()
+	preferences, err := notify.LoadPreferences(writePreferences(t, `
+teams:
+  - slug: team-email
+    channel: email
+  - slug: team-failing
+    channel: email
+  - slug: team-deleted
+    channel: email
+  - slug: team-webhook
+    channel: webhook
+  - slug: team-slack
+    channel: slack
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("unconfigured channel", func(t *testing.T) {
+		_, err := notify.NewRouter(preferences, map[notify.Channel]notify.Notifier{
+			notify.ChannelEmail: &fakeNotifier{},
+		}, "https://console.example.com", log)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+	})
+
+	t.Run("route teams", func(t *testing.T) {
+		email := &fakeNotifier{fail: []string{"team-failing"}}
+		webhook := &fakeNotifier{}
+		router, err := notify.NewRouter(preferences, map[notify.Channel]notify.Notifier{
+			notify.ChannelEmail:   email,
+			notify.ChannelWebhook: webhook,
+		}, "https://console.example.com", log)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		deleted := team("team-deleted")
+		deleted.DeletionInProgress = true
+
+		teams := []naisapi.Team{team("team-email"), team("team-failing"), deleted, team("team-webhook"), team("team-slack"), team("other-team")}
+		passed := make([]string, 0)
+		for team, err := range router.Route(ctx, naisapi.Values(teams)) {
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			passed = append(passed, team.Slug)
+		}
+
+		if expected := []string{"team-deleted", "team-slack", "other-team"}; !slices.Equal(passed, expected) {
+			t.Errorf("expected %v to be passed on to Slack, got: %v", expected, passed)
+		}
+
+		if !slices.Equal(email.notified, []string{"team-email"}) || !slices.Equal(webhook.notified, []string{"team-webhook"}) {
+			t.Errorf("unexpected deliveries, email: %v, webhook: %v", email.notified, webhook.notified)
+		}
+
+		result := router.Result()
+		if !slices.Equal(result.Notified, []string{"team-email", "team-webhook"}) || !slices.Equal(result.Failed, []string{"team-failing"}) {
+			t.Errorf("unexpected result: %+v", result)
+		}
+	})
+
+	t.Run("Slack notifier", func(t *testing.T) {
+		slack := &fakeNotifier{delivered: []string{"team-delivered"}}
+		email := &fakeNotifier{}
+		router, err := notify.NewRouter(preferences, map[notify.Channel]notify.Notifier{
+			notify.ChannelSlack:   slack,
+			notify.ChannelEmail:   email,
+			notify.ChannelWebhook: &fakeNotifier{},
+		}, "https://console.example.com", log)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		deleted := team("team-deleted")
+		deleted.DeletionInProgress = true
+
+		teams := []naisapi.Team{team("team-email"), deleted, team("team-slack"), team("team-delivered")}
+		passed, err := naisapi.Collect(router.Route(ctx, naisapi.Values(teams)))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(passed) != 1 || passed[0].Slug != "team-deleted" {
+			t.Errorf("expected only the deleted team to be passed on, got: %v", passed)
+		}
+
+		if !slices.Equal(slack.notified, []string{"team-slack"}) || !slices.Equal(email.notified, []string{"team-email"}) {
+			t.Errorf("unexpected deliveries, Slack: %v, email: %v", slack.notified, email.notified)
+		}
+
+		result := router.Result()
+		if !slices.Equal(result.Notified, []string{"team-email", "team-slack"}) || !slices.Equal(result.Routed, []string{"team-email"}) || !slices.Equal(result.AlreadyNotified, []string{"team-delivered"}) {
+			t.Errorf("unexpected result: %+v", result)
+		}
+	})
+
+	t.Run("ledger", func(t *testing.T) {
+		store := ledger.NewFileStore(filepath.Join(t.TempDir(), "ledger.json"))
+
+		// team-email got its reminder before the previous run failed
+		err := store.Record(ctx, ledger.Delivery{Period: "2026-10", TeamSlug: "team-email", Kind: ledger.KindPreferredChannel, Recipient: "email"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		email := &fakeNotifier{}
+		webhook := &fakeNotifier{}
+		router, err := notify.NewRouter(preferences, map[notify.Channel]notify.Notifier{
+			notify.ChannelEmail:   email,
+			notify.ChannelWebhook: webhook,
+		}, "https://console.example.com", log, notify.WithLedger(store), notify.WithPeriod("2026-10"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := naisapi.Collect(router.Route(ctx, naisapi.Values([]naisapi.Team{team("team-email"), team("team-webhook")}))); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(email.notified) != 0 || !slices.Equal(webhook.notified, []string{"team-webhook"}) {
+			t.Errorf("unexpected deliveries, email: %v, webhook: %v", email.notified, webhook.notified)
+		}
+
+		result := router.Result()
+		if !slices.Equal(result.Notified, []string{"team-webhook"}) || !slices.Equal(result.AlreadyNotified, []string{"team-email"}) {
+			t.Errorf("unexpected result: %+v", result)
+		}
+
+		delivered, err := store.Delivered(ctx, ledger.Key{Period: "2026-10", TeamSlug: "team-webhook", Recipient: "webhook"})
+		if err != nil || !delivered {
+			t.Errorf("expected the delivery to team-webhook to be recorded, got: %v, %v", delivered, err)
+		}
+	})
+
+	t.Run("no preferences", func(t *testing.T) {
+		router, err := notify.NewRouter(nil, nil, "https://console.example.com", log)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		teams, err := naisapi.Collect(router.Route(ctx, naisapi.Values([]naisapi.Team{team("team1"), team("team2")})))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(teams) != 2 {
+			t.Errorf("expected every team to be passed on, got: %v", teams)
+		}
+	})
+}
+
+// fakeSMTPServer accepts a single SMTP session and sends the recipients and the data of the email on the returned
+// channel.
+func fakeSMTPServer(t *testing.T) (addr *net.TCPAddr, emails <-chan []string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	ch := make(chan []string, 1)
+	go func() {
+		c, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		conn := textproto.NewConn(c)
+		defer func() { _ = conn.Close() }()
+
+		email := make([]string, 0)
+		_ = conn.PrintfLine("220 localhost")
+		for {
+			line, err := conn.ReadLine()
+			if err != nil {
+				return
+			}
+
+			switch {
+			case strings.HasPrefix(line, "RCPT TO:"):
+				email = append(email, strings.TrimPrefix(line, "RCPT TO:"))
+				_ = conn.PrintfLine("250 OK")
+			case line == "DATA":
+				_ = conn.PrintfLine("354 Go ahead")
+				data, err := io.ReadAll(conn.DotReader())
+				if err != nil {
+					return
+				}
+				ch <- append(email, string(data))
+				_ = conn.PrintfLine("250 OK")
+			case line == "QUIT":
+				_ = conn.PrintfLine("221 Bye")
+				return
+			default:
+				_ = conn.PrintfLine("250 OK")
+			}
+		}
+	}()
+
+	return listener.Addr().(*net.TCPAddr), ch
+}
+
+func TestEmail(t *testing.T) {
+	addr, emails := fakeSMTPServer(t)
+	preferences, err := notify.LoadPreferences(writePreferences(t, "teams:\n  - slug: team1\n    channel: email\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	email := notify.NewEmail(notify.SMTPConfig{
+		Host: addr.IP.String(),
+		Port: addr.Port,
+		From: "nais@example.com",
+	}, preferences)
+
+	r := review.New(team("team1"), "https://console.example.com")
+	if err := email.Notify(context.Background(), team("team1"), r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sent := <-emails
+	if sent[0] != "<owner@example.com>" || len(sent) != 2 {
+		t.Errorf("expected the email to be sent to the owner only, got: %v", sent[:len(sent)-1])
+	}
+
+	data := sent[len(sent)-1]
+	for _, want := range []string{"From: nais@example.com\n", "To: owner@example.com\n", "Subject: =?utf-8?q?", "Hei team1!"} {
+		if !strings.Contains(data, want) {
+			t.Errorf("expected the email to contain %q, got:\n%s", want, data)
+		}
+	}
+}
+
+func newWebhook(t *testing.T, defaultURL string, preferences *notify.Preferences) *notify.Webhook {
+	t.Helper()
+	webhook, err := notify.NewWebhook(defaultURL, preferences)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return webhook
+}
+
+func TestWebhook(t *testing.T) {
+	t.Run("post review", func(t *testing.T) {
+		payloads := make(chan notify.WebhookPayload, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			payload := notify.WebhookPayload{}
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			payloads <- payload
+		}))
+		defer server.Close()
+
+		r := review.New(team("team1"), "https://console.example.com")
+		if err := newWebhook(t, server.URL, nil).Notify(context.Background(), team("team1"), r); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		payload := <-payloads
+		if payload.Team != "team1" || payload.Text != r.Text() || len(payload.Members) != 2 || payload.Members[0].Role != "OWNER" {
+			t.Errorf("unexpected payload: %+v", payload)
+		}
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		if err := newWebhook(t, server.URL, nil).Notify(context.Background(), team("team1"), review.Review{}); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+
+	t.Run("missing URL", func(t *testing.T) {
+		if err := newWebhook(t, "", nil).Notify(context.Background(), team("team1"), review.Review{}); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+
+	t.Run("configuration", func(t *testing.T) {
+		preferences, err := notify.LoadPreferences(writePreferences(t, `
+teams:
+  - slug: team-own-url
+    channel: webhook
+    url: https://example.com/hook
+  - slug: team-default-url
+    channel: webhook
+`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := notify.NewWebhook("https://example.com/default", preferences); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if _, err := notify.NewWebhook("", preferences); err == nil {
+			t.Error("expected error for a team without a webhook URL")
+		}
+
+		if _, err := notify.NewWebhook("example.com/hook", nil); err == nil {
+			t.Error("expected error for an invalid default URL")
+		}
+	})
+}
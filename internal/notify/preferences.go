@@ -0,0 +1,124 @@
+package notify
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Preference is the channel a team wants its review reminder delivered through.
+type Preference struct {
+	Channel Channel
+
+	// Recipients are the email addresses the reminder is sent to by the email channel. The reminder is sent to the
+	// owners of the team if empty.
+	Recipients []string
+
+	// URL is the webhook the reminder is posted to by the webhook channel. The default webhook is used if empty.
+	URL string
+}
+
+// Preferences are the channel preferences of the teams. Teams without a preference are notified in Slack.
+type Preferences struct {
+	teams map[string]Preference
+}
+
+// preferencesContent is the format of a preferences file. Since JSON is a subset of YAML, the same format is used for
+// both.
+type preferencesContent struct {
+	Teams []teamPreference `json:"teams" yaml:"teams"`
+}
+
+type teamPreference struct {
+	Slug       string   `json:"slug" yaml:"slug"`
+	Channel    Channel  `json:"channel" yaml:"channel"`
+	Recipients []string `json:"recipients,omitempty" yaml:"recipients"`
+	URL        string   `json:"url,omitempty" yaml:"url"`
+}
+
+// LoadPreferences reads the channel preferences of the teams from a YAML or JSON file.
+func LoadPreferences(path string) (*Preferences, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read channel preferences: %w", err)
+	}
+
+	content := &preferencesContent{}
+	if err := yaml.Unmarshal(data, content); err != nil {
+		return nil, fmt.Errorf("parse channel preferences %q: %w", path, err)
+	}
+
+	preferences := &Preferences{teams: make(map[string]Preference, len(content.Teams))}
+	for _, t := range content.Teams {
+		if t.Slug == "" {
+			return nil, fmt.Errorf("parse channel preferences %q: missing team slug", path)
+		}
+
+		switch t.Channel {
+		case ChannelSlack, ChannelEmail, ChannelWebhook:
+		default:
+			return nil, fmt.Errorf("parse channel preferences %q: unsupported channel %q for team %q", path, t.Channel, t.Slug)
+		}
+
+		if _, ok := preferences.teams[t.Slug]; ok {
+			return nil, fmt.Errorf("parse channel preferences %q: duplicate team %q", path, t.Slug)
+		}
+
+		if t.URL != "" {
+			if err := validateWebhookURL(t.URL); err != nil {
+				return nil, fmt.Errorf("parse channel preferences %q: team %q: %w", path, t.Slug, err)
+			}
+		}
+
+		preferences.teams[t.Slug] = Preference{
+			Channel:    t.Channel,
+			Recipients: t.Recipients,
+			URL:        t.URL,
+		}
+	}
+
+	return preferences, nil
+}
+
+// For returns the preference of the team. A nil Preferences prefers Slack for every team.
+func (p *Preferences) For(teamSlug string) Preference {
+	if p != nil {
+		if preference, ok := p.teams[teamSlug]; ok {
+			return preference
+		}
+	}
+	return Preference{Channel: ChannelSlack}
+}
+
+// Channels returns the channels preferred by at least one team, in no particular order.
+func (p *Preferences) Channels() []Channel {
+	if p == nil {
+		return nil
+	}
+
+	seen := make(map[Channel]struct{})
+	channels := make([]Channel, 0)
+	for _, preference := range p.teams {
+		if _, ok := seen[preference.Channel]; !ok {
+			seen[preference.Channel] = struct{}{}
+			channels = append(channels, preference.Channel)
+		}
+	}
+	return channels
+}
+
+// validateWebhookURL returns an error unless rawURL is an absolute HTTP or HTTPS URL.
+func validateWebhookURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook URL %q: must be an absolute HTTP or HTTPS URL", rawURL)
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"time"
+
+	"github.com/nais/slack-teams-notification/internal/ledger"
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/nais/slack-teams-notification/internal/review"
+	"github.com/sirupsen/logrus"
+)
+
+// Result is the outcome of the teams delivered by a Router.
+type Result struct {
+	// Notified are the slugs of the teams whose reminder was delivered.
+	Notified []string
+
+	// Failed are the slugs of the teams whose reminder could not be delivered.
+	Failed []string
+
+	// AlreadyNotified are the slugs of the teams whose reminder had already been delivered in this period.
+	AlreadyNotified []string
+
+	// Routed are the slugs of the notified teams that prefer a channel other than Slack.
+	Routed []string
+}
+
+// Router delivers the reminder of each team through the notifier of the channel the team prefers. The teams that
+// prefer Slack are passed on if there is no notifier for Slack, e.g. since the Slack notifier sends each owner a digest
+// of all their teams.
+type Router struct {
+	preferences *Preferences
+	notifiers   map[Channel]Notifier
+	consoleURL  string
+	ledger      ledger.Store
+	period      string
+	result      Result
+	log         logrus.FieldLogger
+}
+
+type RouterOption func(*Router)
+
+// WithLedger makes the router record every reminder delivered outside Slack in the ledger, and skip the teams whose
+// reminder has already been delivered in the period, like the Slack notifier does for each owner.
+func WithLedger(store ledger.Store) RouterOption {
+	return func(r *Router) {
+		r.ledger = store
+	}
+}
+
+// WithPeriod sets the run period the deliveries are recorded in. Defaults to the current month.
+func WithPeriod(period string) RouterOption {
+	return func(r *Router) {
+		r.period = period
+	}
+}
+
+// NewRouter returns a router for the channel preferences of the teams. An error is returned if a team prefers a
+// channel other than Slack that has no notifier, so a misconfiguration is found before anyone is notified.
+func NewRouter(preferences *Preferences, notifiers map[Channel]Notifier, consoleURL string, log logrus.FieldLogger, opts ...RouterOption) (*Router, error) {
+	for _, channel := range preferences.Channels() {
+		if _, ok := notifiers[channel]; !ok && channel != ChannelSlack {
+			return nil, fmt.Errorf("channel %q is preferred by a team, but is not configured", channel)
+		}
+	}
+
+	r := &Router{
+		preferences: preferences,
+		notifiers:   notifiers,
+		consoleURL:  consoleURL,
+		period:      ledger.PeriodOf(time.Now()),
+		log:         log,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r, nil
+}
+
+// Route returns an iterator over the teams that are passed on to the Slack notifier. The other teams are delivered
+// through the channel they prefer as the iterator is consumed. Teams that are being deleted or have no members are
+// always passed on, so they are reported together with the other skipped teams.
+func (r *Router) Route(ctx context.Context, teams iter.Seq2[naisapi.Team, error]) iter.Seq2[naisapi.Team, error] {
+	return func(yield func(naisapi.Team, error) bool) {
+		for team, err := range teams {
+			if err != nil {
+				yield(team, err)
+				return
+			}
+
+			channel := r.preferences.For(team.Slug).Channel
+			notifier, ok := r.notifiers[channel]
+			if !ok || team.IsBeingDeleted() || len(team.Members) == 0 {
+				if !yield(team, nil) {
+					return
+				}
+				continue
+			}
+
+			log := r.log.
+				WithField("team_slug", team.Slug).
+				WithField("channel", channel)
+
+			if delivered, err := r.delivered(ctx, team.Slug, channel); err != nil {
+				log.WithError(err).Errorf("check ledger")
+				r.result.Failed = append(r.result.Failed, team.Slug)
+				continue
+			} else if delivered {
+				log.Infof("already notified in this period, skip delivery")
+				r.result.AlreadyNotified = append(r.result.AlreadyNotified, team.Slug)
+				continue
+			}
+
+			if err := notifier.Notify(ctx, team, review.New(team, r.consoleURL)); errors.Is(err, ledger.ErrAlreadyDelivered) {
+				log.Infof("already notified in this period, skip delivery")
+				r.result.AlreadyNotified = append(r.result.AlreadyNotified, team.Slug)
+				continue
+			} else if err != nil {
+				log.WithError(err).Errorf("delivering review reminder")
+				r.result.Failed = append(r.result.Failed, team.Slug)
+				continue
+			}
+
+			log.Infof("delivered review reminder")
+			r.result.Notified = append(r.result.Notified, team.Slug)
+			if channel != ChannelSlack {
+				r.result.Routed = append(r.result.Routed, team.Slug)
+				r.record(ctx, team.Slug, channel, log)
+			}
+		}
+	}
+}
+
+// delivered reports whether the reminder of the team has already been delivered through the channel in this period.
+// The Slack notifier checks the ledger for each owner itself.
+func (r *Router) delivered(ctx context.Context, teamSlug string, channel Channel) (bool, error) {
+	if r.ledger == nil || channel == ChannelSlack {
+		return false, nil
+	}
+
+	return r.ledger.Delivered(ctx, ledger.Key{Period: r.period, TeamSlug: teamSlug, Recipient: string(channel)})
+}
+
+// record records the delivery of the reminder of the team in the ledger. Since the reminder has already been
+// delivered, a failure to record it is only logged.
+func (r *Router) record(ctx context.Context, teamSlug string, channel Channel, log logrus.FieldLogger) {
+	if r.ledger == nil {
+		return
+	}
+
+	err := r.ledger.Record(ctx, ledger.Delivery{
+		Period:    r.period,
+		TeamSlug:  teamSlug,
+		Kind:      ledger.KindPreferredChannel,
+		Recipient: string(channel),
+		SentAt:    time.Now(),
+	})
+	if err != nil {
+		log.WithError(err).Errorf("record delivery in ledger")
+	}
+}
+
+// Result returns the outcome of the teams delivered so far.
+func (r *Router) Result() Result {
+	return r.result
+}
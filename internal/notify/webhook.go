@@ -0,0 +1,112 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/nais/slack-teams-notification/internal/httputils"
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/nais/slack-teams-notification/internal/review"
+)
+
+const webhookTimeout = time.Second * 10
+
+// WebhookPayload is the JSON body posted to the webhook for each team.
+type WebhookPayload struct {
+	Team       string          `json:"team"`
+	Subject    string          `json:"subject"`
+	Text       string          `json:"text"`
+	ConsoleURL string          `json:"consoleUrl"`
+	Members    []WebhookMember `json:"members"`
+}
+
+type WebhookMember struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// Webhook posts the review reminders as JSON to a webhook, so they can be delivered by systems outside of this job.
+type Webhook struct {
+	defaultURL  string
+	preferences *Preferences
+	httpClient  *http.Client
+}
+
+var _ Notifier = (*Webhook)(nil)
+
+// NewWebhook returns a notifier that posts the reminders to the URL in the preference of each team, or to defaultURL
+// if the preference has none. An error is returned if defaultURL is invalid, or if it is empty and a team that prefers
+// the webhook channel has no URL of its own, so a misconfiguration is found before anyone is notified.
+func NewWebhook(defaultURL string, preferences *Preferences) (*Webhook, error) {
+	if defaultURL != "" {
+		if err := validateWebhookURL(defaultURL); err != nil {
+			return nil, err
+		}
+	} else if preferences != nil {
+		for _, slug := range slices.Sorted(maps.Keys(preferences.teams)) {
+			if p := preferences.teams[slug]; p.Channel == ChannelWebhook && p.URL == "" {
+				return nil, fmt.Errorf("team %q prefers the webhook channel, but has no webhook URL and no default is configured", slug)
+			}
+		}
+	}
+
+	return &Webhook{
+		defaultURL:  defaultURL,
+		preferences: preferences,
+		httpClient:  &http.Client{Timeout: webhookTimeout},
+	}, nil
+}
+
+func (w *Webhook) Notify(ctx context.Context, team naisapi.Team, r review.Review) error {
+	url := w.preferences.For(team.Slug).URL
+	if url == "" {
+		url = w.defaultURL
+	}
+
+	if url == "" {
+		return fmt.Errorf("no webhook URL for team %q", team.Slug)
+	}
+
+	members := make([]WebhookMember, 0, len(team.Members))
+	for _, m := range team.Members {
+		members = append(members, WebhookMember(m))
+	}
+
+	body, err := json.Marshal(WebhookPayload{
+		Team:       team.Slug,
+		Subject:    r.Subject,
+		Text:       r.Text(),
+		ConsoleURL: r.ConsoleURL,
+		Members:    members,
+	})
+	if err != nil {
+		return fmt.Errorf("encode webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", httputils.UserAgent)
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("post to webhook: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("post to webhook: unexpected status %s", resp.Status)
+	}
+	return nil
+}
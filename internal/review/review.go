@@ -0,0 +1,160 @@
+// Package review renders the reminder asking the owners of a team to review its members. Every channel the reminder
+// is delivered through uses the same text, and only decides how to format it, e.g. as Block Kit in Slack or as plain
+// text in an email.
+package review
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+)
+
+// Responsibility explains why the owners are asked to review the members.
+const Responsibility = "Dere er ansvarlige for å holde teamets medlemsliste oppdatert. Siden medlemskap i Nais-team gir utvidede rettigheter til blant annet produksjonsmiljø og persondata, er det viktig å holde teamet oppdatert."
+
+// Review is the reminder about a single team.
+type Review struct {
+	TeamSlug string
+	Purpose  string
+
+	// Subject is a single line summary of the reminder, e.g. the subject of an email.
+	Subject string
+
+	Members []string
+	Owners  []string
+
+	// Grants describe the environments and external resources that membership in the team grants access to.
+	Grants []string
+
+	// ConsoleURL is the page in Console where the members of the team are administered.
+	ConsoleURL string
+
+	// OwnerWarning tells the owners that the team has too few owners. It is empty if the team has enough.
+	OwnerWarning string
+}
+
+// New renders the reminder for the team, with links to the Console frontend at consoleURL.
+func New(team naisapi.Team, consoleURL string) Review {
+	r := Review{
+		TeamSlug:   team.Slug,
+		Purpose:    team.Purpose,
+		Subject:    fmt.Sprintf("Påminnelse om å holde %q-teamet oppdatert", team.Slug),
+		Members:    make([]string, 0),
+		Owners:     make([]string, 0),
+		Grants:     MembershipGrants(team),
+		ConsoleURL: TeamMembersURL(consoleURL, team.Slug),
+	}
+
+	for _, member := range team.Members {
+		r.Members = append(r.Members, member.Name)
+		if member.IsOwner() {
+			r.Owners = append(r.Owners, member.Name)
+		}
+	}
+
+	r.OwnerWarning = OwnerWarning(len(r.Owners))
+	return r
+}
+
+// Greeting returns the first line of the reminder.
+func (r Review) Greeting() string {
+	return fmt.Sprintf("Hei %s!", r.TeamSlug)
+}
+
+// MembersIntro introduces the lists of members and owners.
+func (r Review) MembersIntro() string {
+	return fmt.Sprintf("Følgende brukere er i dag registrert som medlemmer og eiere i %s:", r.TeamSlug)
+}
+
+// GrantsIntro introduces the list of grants.
+func (r Review) GrantsIntro() string {
+	return fmt.Sprintf("Medlemskap i %s gir blant annet tilgang til:", r.TeamSlug)
+}
+
+// Question asks the owners whether the members are correct, and where to change them. The link to Console is formatted
+// by the channel.
+func Question(consoleLink string) string {
+	return "Ser dette korrekt ut? Om ikke kan dere administrere teamet i " + consoleLink
+}
+
+// Text returns the reminder as plain text, for the channels that do not format it themselves.
+func (r Review) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", r.Greeting())
+
+	if r.Purpose != "" {
+		fmt.Fprintf(&b, "Formål: %s\n\n", r.Purpose)
+	}
+
+	fmt.Fprintf(&b, "%s\n\n", Responsibility)
+	fmt.Fprintf(&b, "%s\n\n", r.MembersIntro())
+
+	writeList(&b, "Medlemmer:", r.Members)
+	if len(r.Owners) > 0 {
+		writeList(&b, "Eiere:", r.Owners)
+	}
+
+	if len(r.Grants) > 0 {
+		writeList(&b, r.GrantsIntro(), r.Grants)
+	}
+
+	fmt.Fprintf(&b, "%s\n", Question("Console: "+r.ConsoleURL))
+
+	if r.OwnerWarning != "" {
+		fmt.Fprintf(&b, "\nNB! %s\n", r.OwnerWarning)
+	}
+
+	return b.String()
+}
+
+func writeList(b *strings.Builder, heading string, items []string) {
+	fmt.Fprintf(b, "%s\n", heading)
+	for _, item := range items {
+		fmt.Fprintf(b, "- %s\n", item)
+	}
+	b.WriteString("\n")
+}
+
+// OwnerWarning returns a warning to include in the reminder if the team has too few owners, or an empty string.
+func OwnerWarning(ownerCount int) string {
+	if ownerCount == 0 {
+		return "Teamet har ingen eier, ta kontakt med Nais-teamet på #utviklerrommet for å få lagt inn en eier."
+	} else if ownerCount < 2 {
+		return "Det bør være minst to eiere av hvert team."
+	}
+	return ""
+}
+
+// MembershipGrants returns a description of the environments and external resources that membership in the team
+// grants access to.
+func MembershipGrants(team naisapi.Team) []string {
+	grants := make([]string, 0)
+	for _, env := range team.Environments {
+		if env.GCPProjectID != "" {
+			grants = append(grants, fmt.Sprintf("Miljø: %s (GCP-prosjekt %s)", env.Name, env.GCPProjectID))
+		} else {
+			grants = append(grants, fmt.Sprintf("Miljø: %s", env.Name))
+		}
+	}
+
+	if r := team.ExternalResources.GitHubTeam; r != "" {
+		grants = append(grants, fmt.Sprintf("GitHub-team: %s", r))
+	}
+
+	if r := team.ExternalResources.GoogleGroup; r != "" {
+		grants = append(grants, fmt.Sprintf("Google-gruppe: %s", r))
+	}
+
+	if r := team.ExternalResources.EntraIDGroup; r != "" {
+		grants = append(grants, fmt.Sprintf("Entra ID-gruppe: %s", r))
+	}
+
+	return grants
+}
+
+// TeamMembersURL returns the page in the Console frontend at baseURL where the members of the team are administered.
+func TeamMembersURL(baseURL, teamSlug string) string {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	return fmt.Sprintf("%s/team/%s/members", baseURL, teamSlug)
+}
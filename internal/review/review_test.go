@@ -0,0 +1,49 @@
+package review_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/nais/slack-teams-notification/internal/review"
+)
+
+func TestNew(t *testing.T) {
+	team := naisapi.Team{
+		Slug: "team1",
+		Members: []naisapi.Member{
+			{Name: "Owner", Email: "owner@example.com", Role: "OWNER"},
+			{Name: "Member", Email: "member@example.com", Role: "MEMBER"},
+		},
+		Environments: []naisapi.Environment{{Name: "prod", GCPProjectID: "project-123"}},
+	}
+
+	r := review.New(team, "https://console.example.com/")
+	if r.ConsoleURL != "https://console.example.com/team/team1/members" {
+		t.Errorf("unexpected Console URL: %q", r.ConsoleURL)
+	}
+
+	if !slices.Equal(r.Members, []string{"Owner", "Member"}) || !slices.Equal(r.Owners, []string{"Owner"}) {
+		t.Errorf("unexpected members: %v, owners: %v", r.Members, r.Owners)
+	}
+
+	if r.OwnerWarning == "" {
+		t.Error("expected a warning about too few owners")
+	}
+
+	text := r.Text()
+	for _, want := range []string{"Hei team1!", "Medlemmer:\n- Owner\n- Member\n", "Eiere:\n- Owner\n", "- Miljø: prod (GCP-prosjekt project-123)\n", r.ConsoleURL, "NB! Det bør være minst to eiere"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected the review to contain %q, got:\n%s", want, text)
+		}
+	}
+}
+
+func TestOwnerWarning(t *testing.T) {
+	for count, expectWarning := range map[int]bool{0: true, 1: true, 2: false, 3: false} {
+		if warning := review.OwnerWarning(count); (warning != "") != expectWarning {
+			t.Errorf("unexpected warning for %d owners: %q", count, warning)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	slackapi "github.com/slack-go/slack"
+)
+
+// ErrFatal is returned when Slack responds with an error that will fail every other call as well, e.g. when the token
+// has been revoked. The run should be aborted.
+var ErrFatal = errors.New("fatal Slack API error")
+
+// fatalErrors are the errors from the Slack API that are not specific to a recipient or a message.
+var fatalErrors = []string{
+	"account_inactive",
+	"ekm_access_denied",
+	"invalid_auth",
+	"missing_scope",
+	"no_permission",
+	"not_authed",
+	"org_login_required",
+	"token_expired",
+	"token_revoked",
+}
+
+// isFatal reports whether the error from the Slack API will fail every other call as well.
+func isFatal(err error) bool {
+	var slackErr slackapi.SlackErrorResponse
+	return errors.As(err, &slackErr) && slices.Contains(fatalErrors, slackErr.Err)
+}
+
+var (
+	// NotifyScopes are the scopes the Slack token needs to notify the teams.
+	NotifyScopes = []string{"chat:write", "users:read", "users:read.email"}
+
+	// ReactionScopes are the scopes the Slack token needs to check the reactions to the notifications.
+	ReactionScopes = []string{"reactions:read"}
+)
+
+type authTestResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+	Team  string `json:"team"`
+	User  string `json:"user"`
+}
+
+// CheckAuth verifies with auth.test that the Slack token is valid and has been granted all the scopes. The returned
+// error wraps ErrFatal if it is not, so the run can be aborted before anything is fetched or posted.
+func (n *Notifier) CheckAuth(ctx context.Context, scopes ...string) error {
+	apiURL := n.slackAPIURL
+	if apiURL == "" {
+		apiURL = slackapi.APIURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL+"auth.test", nil)
+	if err != nil {
+		return fmt.Errorf("create auth.test request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+n.slackToken)
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("auth.test: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("auth.test: unexpected HTTP status code %d", resp.StatusCode)
+	}
+
+	var body authTestResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("decode auth.test response: %w", err)
+	}
+
+	if !body.OK {
+		return fmt.Errorf("%w: auth.test: %s", ErrFatal, body.Error)
+	}
+
+	granted := make([]string, 0)
+	for scope := range strings.SplitSeq(resp.Header.Get("X-OAuth-Scopes"), ",") {
+		granted = append(granted, strings.TrimSpace(scope))
+	}
+
+	missing := make([]string, 0)
+	for _, scope := range scopes {
+		if !slices.Contains(granted, scope) {
+			missing = append(missing, scope)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: the Slack token is missing the scopes %s", ErrFatal, strings.Join(missing, ", "))
+	}
+
+	n.log.
+		WithField("slack_team", body.Team).
+		WithField("slack_user", body.User).
+		Infof("authenticated with Slack")
+	return nil
+}
@@ -0,0 +1,99 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+)
+
+func TestCheckAuth(t *testing.T) {
+	ctx := context.Background()
+
+	tests := map[string]struct {
+		response    string
+		scopes      string
+		expectedErr string
+	}{
+		"valid token with all scopes": {
+			response: `{"ok": true, "team": "nav", "user": "notifier"}`,
+			scopes:   "chat:write,users:read,users:read.email,reactions:read",
+		},
+		"missing scopes": {
+			response:    `{"ok": true, "team": "nav", "user": "notifier"}`,
+			scopes:      "chat:write, users:read",
+			expectedErr: "missing the scopes users:read.email",
+		},
+		"revoked token": {
+			response:    `{"ok": false, "error": "token_revoked"}`,
+			expectedErr: "token_revoked",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := newFakeSlack(t, `[]`)
+			f.handlers["auth.test"] = func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer token" {
+					t.Errorf("expected the token to be sent, got: %q", r.Header.Get("Authorization"))
+				}
+				w.Header().Set("X-OAuth-Scopes", tc.scopes)
+				_, _ = w.Write([]byte(tc.response))
+			}
+
+			err := newTestNotifier(t, f).CheckAuth(ctx, NotifyScopes...)
+			if tc.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrFatal) || !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Fatalf("expected fatal error containing %q, got: %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestNotifyTeams_FatalError(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeSlack(t, `[{"id": "U1", "name": "owner1", "profile": {"email": "owner1@example.com"}}]`)
+
+	calls := 0
+	f.handlers["chat.postMessage"] = func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		_, _ = w.Write([]byte(`{"ok": false, "error": "token_revoked"}`))
+	}
+
+	owner := naisapi.Member{Name: "Owner 1", Email: "owner1@example.com", Role: "OWNER"}
+	teams := []naisapi.Team{
+		{Slug: "team1", Members: []naisapi.Member{owner}},
+		{Slug: "team2", Members: []naisapi.Member{owner}},
+		{Slug: "team3", Members: []naisapi.Member{owner}},
+	}
+
+	for name, opts := range map[string][]NotifierOption{
+		"per team": nil,
+		"digest":   {WithDigest()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			calls = 0
+			result, err := newTestNotifier(t, f, opts...).NotifyTeams(ctx, naisapi.Values(teams))
+			if !errors.Is(err, ErrFatal) {
+				t.Fatalf("expected fatal error, got: %v", err)
+			}
+
+			if calls != 1 {
+				t.Errorf("expected the run to stop after the first fatal error, got %d calls", calls)
+			}
+
+			if len(result.Notified) != 0 {
+				t.Errorf("expected no teams to be notified, got: %v", result.Notified)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/nais/slack-teams-notification/internal/ledger"
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/nais/slack-teams-notification/internal/review"
+	"github.com/sirupsen/logrus"
+)
+
+// owner is a team owner along with all the teams they own.
+type owner struct {
+	name  string
+	email string
+	teams []naisapi.Team
+}
+
+// groupByOwner groups the teams by the email address of their owners, sorted by email. Teams without owners are
+// returned separately.
+func groupByOwner(teams []naisapi.Team) (owners []*owner, ownerless []naisapi.Team) {
+	byEmail := make(map[string]*owner)
+	for _, team := range teams {
+		hasOwner := false
+		for _, member := range team.Members {
+			if !member.IsOwner() {
+				continue
+			}
+
+			hasOwner = true
+			key := strings.ToLower(member.Email)
+			o, exists := byEmail[key]
+			if !exists {
+				o = &owner{name: member.Name, email: member.Email}
+				byEmail[key] = o
+				owners = append(owners, o)
+			}
+			o.teams = append(o.teams, team)
+		}
+
+		if !hasOwner {
+			ownerless = append(ownerless, team)
+		}
+	}
+
+	slices.SortFunc(owners, func(a, b *owner) int {
+		return strings.Compare(strings.ToLower(a.email), strings.ToLower(b.email))
+	})
+
+	return owners, ownerless
+}
+
+// notifyOwners sends a single digest to each owner of the teams. Teams where none of the owners can be found in Slack
+// are notified in their Slack channel, like when not running in digest mode. Only a fatal error from Slack is returned.
+func (n *Notifier) notifyOwners(ctx context.Context, teams []naisapi.Team, result *Result) error {
+	owners, _ := groupByOwner(teams)
+	slackUserIDs := make(map[*owner]string)
+	resolved := make(map[string]bool)
+	unresolved := make(map[string]bool)
+
+	for _, o := range owners {
+		log := n.log.WithField("owner_email", o.email)
+		slackUser, err := n.users.lookup(ctx, o.email)
+		if errors.Is(err, ErrUserNotFound) {
+			log.Warnf("unable to find team owner in Slack")
+			unresolved[strings.ToLower(o.email)] = true
+			result.addUnresolvedOwner(o.email)
+			continue
+		} else if err != nil {
+			log.WithError(err).Errorf("look up owner in Slack")
+			result.addError(o.email, teamSlugs(o.teams), err)
+
+			if err := n.limiter.fatalError(); err != nil {
+				return err
+			}
+			continue
+		}
+		slackUserIDs[o] = slackUser.ID
+		resolved[strings.ToLower(o.email)] = true
+	}
+
+	// The owners that were not found are mentioned in the section of each of their teams
+	unresolvedOwners := make(map[string][]naisapi.Member)
+	for _, team := range teams {
+		for _, member := range team.Members {
+			if member.IsOwner() && unresolved[strings.ToLower(member.Email)] {
+				unresolvedOwners[team.Slug] = append(unresolvedOwners[team.Slug], member)
+			}
+		}
+	}
+
+	notified := make(map[string]bool)
+	alreadyNotified := make(map[string]bool)
+	failed := make(map[string]bool)
+	for _, o := range owners {
+		slackUserID, ok := slackUserIDs[o]
+		if !ok {
+			continue
+		}
+
+		log := n.log.WithFields(logrus.Fields{
+			"owner_email": o.email,
+			"team_count":  len(o.teams),
+		})
+
+		// Only the teams the owner has not been notified about in this period are included, so a retried run does not
+		// send the same digest twice
+		teams := make([]naisapi.Team, 0, len(o.teams))
+		for _, team := range o.teams {
+			delivered, err := n.delivered(ctx, team.Slug, slackUserID)
+			if err != nil {
+				log.WithError(err).WithField("team_slug", team.Slug).Errorf("check ledger")
+				result.addError(slackUserID, []string{team.Slug}, err)
+				failed[team.Slug] = true
+				continue
+			} else if delivered {
+				alreadyNotified[team.Slug] = true
+				continue
+			}
+			teams = append(teams, team)
+		}
+
+		if len(teams) == 0 {
+			log.Infof("already notified in this period, skip notification")
+			continue
+		}
+
+		messages := getDigestMessages(o.name, teams, n.consoleFrontendURL, unresolvedOwners, n.ackButtons, n.maxDigestBlocks())
+		if n.postMessages(ctx, slackUserID, messages, result, log) {
+			for _, team := range teams {
+				notified[team.Slug] = true
+			}
+			continue
+		}
+
+		for _, team := range teams {
+			failed[team.Slug] = true
+		}
+
+		if err := n.limiter.fatalError(); err != nil {
+			return err
+		}
+	}
+
+	for _, team := range teams {
+		if hasOwnerIn(team, resolved) {
+			continue
+		}
+
+		if err := n.notifyTeam(ctx, team, review.New(team, n.consoleFrontendURL), result); errors.Is(err, ledger.ErrAlreadyDelivered) {
+			alreadyNotified[team.Slug] = true
+			continue
+		} else if err != nil {
+			n.log.
+				WithError(err).
+				WithField("team_slug", team.Slug).
+				WithField("slack_channel", team.SlackChannel).
+				Errorf("posting message to Slack")
+
+			if err := n.limiter.fatalError(); err != nil {
+				return err
+			}
+			continue
+		}
+		notified[team.Slug] = true
+	}
+
+	for _, team := range teams {
+		// A team counts as notified if any owner got a message in this run, and as skipped if none did, but some owner had
+		// already been notified earlier in the period and no owner failed
+		if notified[team.Slug] {
+			result.Notified = append(result.Notified, team.Slug)
+		} else if alreadyNotified[team.Slug] && !failed[team.Slug] {
+			result.AlreadyNotified = append(result.AlreadyNotified, team.Slug)
+		} else {
+			result.Failed = append(result.Failed, team.Slug)
+		}
+	}
+
+	return nil
+}
+
+// hasOwnerIn reports whether any of the owners of the team has an email address in the set.
+func hasOwnerIn(team naisapi.Team, emails map[string]bool) bool {
+	for _, member := range team.Members {
+		if member.IsOwner() && emails[strings.ToLower(member.Email)] {
+			return true
+		}
+	}
+	return false
+}
+
+func teamSlugs(teams []naisapi.Team) []string {
+	slugs := make([]string, len(teams))
+	for i, team := range teams {
+		slugs[i] = team.Slug
+	}
+	return slugs
+}
+
+// maxDigestBlocks returns the number of blocks each digest message can use. When redirecting, one block is kept free
+// for the note the redirect poster adds at the top of each message.
+func (n *Notifier) maxDigestBlocks() int {
+	if n.redirectRecipient != "" {
+		return maxBlocksPerMessage - 1
+	}
+	return maxBlocksPerMessage
+}
@@ -0,0 +1,70 @@
+package slack
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+)
+
+func TestGroupByOwner(t *testing.T) {
+	teams := []naisapi.Team{
+		{
+			Slug: "team1",
+			Members: []naisapi.Member{
+				{Name: "User B", Email: "b@example.com", Role: "OWNER"},
+				{Name: "User A", Email: "a@example.com", Role: "OWNER"},
+			},
+		},
+		{
+			Slug: "team2",
+			Members: []naisapi.Member{
+				{Name: "User A", Email: "A@example.com", Role: "OWNER"},
+				{Name: "User B", Email: "b@example.com", Role: "MEMBER"},
+			},
+		},
+		{
+			Slug:    "team3",
+			Members: []naisapi.Member{{Name: "User B", Email: "b@example.com", Role: "MEMBER"}},
+		},
+	}
+
+	owners, ownerless := groupByOwner(teams)
+	if len(owners) != 2 {
+		t.Fatalf("expected 2 owners, got: %d", len(owners))
+	}
+
+	if owners[0].email != "a@example.com" || len(owners[0].teams) != 2 {
+		t.Errorf("expected a@example.com to own 2 teams, got: %+v", owners[0])
+	}
+
+	if owners[1].email != "b@example.com" || len(owners[1].teams) != 1 || owners[1].teams[0].Slug != "team1" {
+		t.Errorf("expected b@example.com to own team1, got: %+v", owners[1])
+	}
+
+	if len(ownerless) != 1 || ownerless[0].Slug != "team3" {
+		t.Errorf("expected team3 to be ownerless, got: %v", ownerless)
+	}
+}
+
+func TestGetDigestMessages(t *testing.T) {
+	teams := make([]naisapi.Team, 0)
+	for i := range 2 {
+		teams = append(teams, naisapi.Team{
+			Slug:    fmt.Sprintf("team%d", i),
+			Members: []naisapi.Member{{Name: "User", Email: "user@example.com", Role: "OWNER"}},
+		})
+	}
+
+	if messages := getDigestMessages("User", teams, "https://console.example.com", nil, false, maxBlocksPerMessage); len(messages) != 1 {
+		t.Errorf("expected a single message for 2 teams, got: %d", len(messages))
+	}
+
+	for i := range 30 {
+		teams = append(teams, naisapi.Team{Slug: fmt.Sprintf("other-team%d", i)})
+	}
+
+	if messages := getDigestMessages("User", teams, "https://console.example.com", nil, false, maxBlocksPerMessage); len(messages) < 2 {
+		t.Errorf("expected the digest to be split into several messages, got: %d", len(messages))
+	}
+}
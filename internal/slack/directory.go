@@ -0,0 +1,221 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	slackapi "github.com/slack-go/slack"
+)
+
+// usersPageSize is the number of users fetched per users.list call. Slack recommends no more than 200.
+const usersPageSize = 200
+
+// defaultUserCacheTTL is how long the user directory is used before it is fetched again, unless set with WithUserCache.
+const defaultUserCacheTTL = 24 * time.Hour
+
+// ErrUserNotFound is returned when no active Slack user has the requested email address.
+var ErrUserNotFound = errors.New("no Slack user with this email address")
+
+type directoryUser struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	RealName string `json:"realName"`
+}
+
+type userCache struct {
+	CreatedAt time.Time                `json:"createdAt"`
+	Users     map[string]directoryUser `json:"users"`
+}
+
+// userDirectory resolves Slack users by email address from an index of all users in the workspace. The index is built
+// from users.list the first time a user is looked up, which replaces one users.lookupByEmail call per owner with a
+// handful of paginated calls per run. The index can optionally be cached on disk between runs. The directory is safe
+// for concurrent use.
+type userDirectory struct {
+	api       *slackapi.Client
+	limiter   *rateLimiter
+	cachePath string
+	cacheTTL  time.Duration
+	log       logrus.FieldLogger
+
+	mu        sync.Mutex
+	loaded    bool
+	loadedAt  time.Time
+	loadErr   error
+	users     map[string]directoryUser
+	reloading bool
+}
+
+func newUserDirectory(api *slackapi.Client, limiter *rateLimiter, cachePath string, cacheTTL time.Duration, log logrus.FieldLogger) *userDirectory {
+	return &userDirectory{
+		api:       api,
+		limiter:   limiter,
+		cachePath: cachePath,
+		cacheTTL:  cacheTTL,
+		log:       log,
+	}
+}
+
+// lookup returns the Slack user with the given email address, or ErrUserNotFound if there is none.
+func (d *userDirectory) lookup(ctx context.Context, email string) (directoryUser, error) {
+	users, err := d.index(ctx)
+	if err != nil {
+		return directoryUser{}, fmt.Errorf("load Slack user directory: %w", err)
+	}
+
+	user, ok := users[strings.ToLower(email)]
+	if !ok {
+		return directoryUser{}, fmt.Errorf("%w: %q", ErrUserNotFound, email)
+	}
+
+	return user, nil
+}
+
+// index returns the index of the users, which is loaded the first time it is needed. The directory lives as long as
+// the server in the "serve" mode, so once the index is older than the TTL of the cache it is loaded again in the
+// background. The old index is used until the new one is ready, so a lookup never waits for more than the first load.
+func (d *userDirectory) index(ctx context.Context) (map[string]directoryUser, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.loaded {
+		d.users, d.loadErr = d.load(ctx)
+		d.loaded = true
+		d.loadedAt = time.Now()
+	} else if d.cacheTTL > 0 && time.Since(d.loadedAt) > d.cacheTTL && !d.reloading {
+		d.reloading = true
+		go d.reload()
+	}
+
+	return d.users, d.loadErr
+}
+
+// reload loads the index again and replaces the old one. The old index is kept if the load fails, and the load is tried
+// again on the next lookup.
+func (d *userDirectory) reload() {
+	users, err := d.load(context.Background())
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.reloading = false
+	if err != nil {
+		d.log.WithError(err).Warnf("unable to reload Slack user directory, using the old one")
+		return
+	}
+
+	d.users = users
+	d.loadErr = nil
+	d.loadedAt = time.Now()
+}
+
+func (d *userDirectory) load(ctx context.Context) (map[string]directoryUser, error) {
+	if d.cachePath != "" {
+		users, err := d.readCache()
+		if err == nil {
+			return users, nil
+		}
+		d.log.WithError(err).Infof("unable to use Slack user cache, fetching users from Slack")
+	}
+
+	users, err := d.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.cachePath != "" {
+		if err := d.writeCache(users); err != nil {
+			d.log.WithError(err).Warnf("unable to write Slack user cache")
+		}
+	}
+
+	return users, nil
+}
+
+func (d *userDirectory) fetch(ctx context.Context) (map[string]directoryUser, error) {
+	users := make(map[string]directoryUser)
+	pages := 0
+	p := d.api.GetUsersPaginated(slackapi.GetUsersOptionLimit(usersPageSize))
+	for {
+		err := d.limiter.call(ctx, "users.list", "", func() error {
+			next, err := p.Next(ctx)
+			if err == nil || p.Done(err) {
+				p = next
+			}
+			return err
+		})
+		if p.Done(err) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		pages++
+		for _, user := range p.Users {
+			if user.Deleted || user.IsBot || user.Profile.Email == "" {
+				continue
+			}
+			users[strings.ToLower(user.Profile.Email)] = directoryUser{
+				ID:       user.ID,
+				Name:     user.Name,
+				RealName: user.RealName,
+			}
+		}
+	}
+
+	d.log.WithFields(logrus.Fields{
+		"pages":      pages,
+		"user_count": len(users),
+	}).Infof("fetched Slack user directory")
+
+	return users, nil
+}
+
+func (d *userDirectory) readCache() (map[string]directoryUser, error) {
+	data, err := os.ReadFile(d.cachePath)
+	if err != nil {
+		return nil, err
+	}
+
+	cache := &userCache{}
+	if err := json.Unmarshal(data, cache); err != nil {
+		return nil, err
+	}
+
+	if age := time.Since(cache.CreatedAt); age > d.cacheTTL {
+		return nil, fmt.Errorf("cache is %v old, which is older than the TTL of %v", age.Round(time.Second), d.cacheTTL)
+	}
+
+	d.log.WithFields(logrus.Fields{
+		"created_at": cache.CreatedAt,
+		"user_count": len(cache.Users),
+	}).Infof("using cached Slack user directory")
+
+	return cache.Users, nil
+}
+
+func (d *userDirectory) writeCache(users map[string]directoryUser) error {
+	data, err := json.Marshal(userCache{
+		CreatedAt: time.Now(),
+		Users:     users,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(d.cachePath), 0o700); err != nil {
+		return err
+	}
+
+	// The cache contains email addresses, so keep it private
+	return os.WriteFile(d.cachePath, data, 0o600)
+}
@@ -0,0 +1,128 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	slackapi "github.com/slack-go/slack"
+)
+
+func TestUserDirectory(t *testing.T) {
+	ctx := context.Background()
+	log, _ := logrustest.NewNullLogger()
+
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		if r.FormValue("cursor") == "" {
+			_, _ = w.Write([]byte(`{
+				"ok": true,
+				"members": [
+					{"id": "U1", "name": "user1", "profile": {"email": "User1@example.com"}},
+					{"id": "U2", "name": "user2", "deleted": true, "profile": {"email": "user2@example.com"}}
+				],
+				"response_metadata": {"next_cursor": "page2"}
+			}`))
+			return
+		}
+
+		_, _ = w.Write([]byte(`{
+			"ok": true,
+			"members": [
+				{"id": "U3", "name": "user3", "profile": {"email": "user3@example.com"}},
+				{"id": "B1", "name": "bot", "is_bot": true, "profile": {"email": "bot@example.com"}}
+			],
+			"response_metadata": {"next_cursor": ""}
+		}`))
+	}))
+	defer ts.Close()
+
+	api := slackapi.New("token", slackapi.OptionAPIURL(ts.URL+"/"))
+	cachePath := filepath.Join(t.TempDir(), "users.json")
+
+	directory := newUserDirectory(api, newTestRateLimiter(log), cachePath, time.Hour, log)
+	for email, expectedID := range map[string]string{"user1@example.com": "U1", "USER3@example.com": "U3"} {
+		user, err := directory.lookup(ctx, email)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", email, err)
+		} else if user.ID != expectedID {
+			t.Errorf("expected %q to resolve to %q, got: %q", email, expectedID, user.ID)
+		}
+	}
+
+	for _, email := range []string{"user2@example.com", "bot@example.com", "unknown@example.com"} {
+		if _, err := directory.lookup(ctx, email); !errors.Is(err, ErrUserNotFound) {
+			t.Errorf("expected user not found for %q, got: %v", email, err)
+		}
+	}
+
+	if requests != 2 {
+		t.Errorf("expected users to be listed once with 2 pages, got %d requests", requests)
+	}
+
+	cached := newUserDirectory(api, newTestRateLimiter(log), cachePath, time.Hour, log)
+	if _, err := cached.lookup(ctx, "user1@example.com"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if requests != 2 {
+		t.Errorf("expected cached directory to be used, got %d requests", requests)
+	}
+
+	expired := newUserDirectory(api, newTestRateLimiter(log), cachePath, 0, log)
+	if _, err := expired.lookup(ctx, "user1@example.com"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if requests != 4 {
+		t.Errorf("expected expired cache to be refreshed, got %d requests", requests)
+	}
+}
+
+func TestUserDirectory_Reload(t *testing.T) {
+	ctx := context.Background()
+	log, _ := logrustest.NewNullLogger()
+
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if requests.Add(1) == 1 {
+			_, _ = w.Write([]byte(`{"ok": true, "members": [{"id": "U1", "name": "user1", "profile": {"email": "user1@example.com"}}]}`))
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"ok": true, "members": [{"id": "U2", "name": "user2", "profile": {"email": "user2@example.com"}}]}`))
+	}))
+	defer ts.Close()
+
+	api := slackapi.New("token", slackapi.OptionAPIURL(ts.URL+"/"))
+	directory := newUserDirectory(api, newTestRateLimiter(log), "", time.Millisecond, log)
+	if _, err := directory.lookup(ctx, "user1@example.com"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	// The expired directory is still used while it is reloaded in the background
+	if _, err := directory.lookup(ctx, "user1@example.com"); err != nil {
+		t.Fatalf("expected the old directory to be used during the reload, got: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		user, err := directory.lookup(ctx, "user2@example.com")
+		if err == nil {
+			if user.ID != "U2" {
+				t.Errorf("expected reloaded user to resolve to %q, got: %q", "U2", user.ID)
+			}
+			break
+		} else if time.Now().After(deadline) {
+			t.Fatalf("expected the directory to be reloaded, got: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
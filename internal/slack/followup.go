@@ -0,0 +1,220 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"slices"
+	"time"
+
+	"github.com/nais/slack-teams-notification/internal/acks"
+	"github.com/nais/slack-teams-notification/internal/ledger"
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/sirupsen/logrus"
+)
+
+// FollowUpPolicy decides when and where teams whose owners have not answered the reminder are followed up.
+type FollowUpPolicy struct {
+	// After is how long after the reminder the owners get a second reminder.
+	After time.Duration
+
+	// EscalateAfter is how long after the second reminder the Slack channel of the team is notified, and how long
+	// after that the admin channel of the notifier is notified, if any.
+	EscalateAfter time.Duration
+}
+
+// escalation is a step in the follow-up of a team, which is taken a given time after the previous step.
+type escalation struct {
+	kind  ledger.Kind
+	after time.Duration
+}
+
+func (p FollowUpPolicy) escalations(team naisapi.Team, adminChannel string) []escalation {
+	escalations := []escalation{{kind: ledger.KindFollowUp, after: p.After}}
+	if team.SlackChannel != "" {
+		escalations = append(escalations, escalation{kind: ledger.KindTeamChannel, after: p.EscalateAfter})
+	}
+
+	if adminChannel != "" {
+		escalations = append(escalations, escalation{kind: ledger.KindAdminChannel, after: p.EscalateAfter})
+	}
+
+	return escalations
+}
+
+// WithAcknowledgements makes the notifier use the answers from the owners in the store to decide which teams to follow
+// up.
+func WithAcknowledgements(store acks.Store) NotifierOption {
+	return func(n *Notifier) {
+		n.acks = store
+	}
+}
+
+// FollowUp follows up the teams whose owners have not answered the latest reminder in the ledger. The owners first get
+// a second reminder, then the Slack channel of the team and finally the admin channel are notified, as decided by the
+// policy. Each step is taken at most once per reminder, so the follow-up can run as often as needed.
+func (n *Notifier) FollowUp(ctx context.Context, teams iter.Seq2[naisapi.Team, error], policy FollowUpPolicy) error {
+	if n.ledger == nil || n.acks == nil {
+		return fmt.Errorf("follow-up requires a ledger and an acknowledgement store")
+	}
+
+	sent := make(map[ledger.Kind][]string)
+	failed := make([]string, 0)
+	defer func() {
+		n.log.WithFields(logrus.Fields{
+			"teams_followed_up":          sent[ledger.KindFollowUp],
+			"teams_escalated_to_channel": sent[ledger.KindTeamChannel],
+			"teams_escalated_to_admins":  sent[ledger.KindAdminChannel],
+			"teams_failed":               failed,
+		}).Infof("follow-up run summary")
+	}()
+
+	for team, err := range teams {
+		if err != nil {
+			return err
+		}
+
+		if team.IsBeingDeleted() || len(team.Members) == 0 {
+			continue
+		}
+
+		kind, err := n.followUpTeam(ctx, team, policy, time.Now())
+		if err != nil {
+			n.log.
+				WithError(err).
+				WithField("team_slug", team.Slug).
+				Errorf("follow up team")
+			failed = append(failed, team.Slug)
+
+			if err := n.limiter.fatalError(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if kind != "" {
+			sent[kind] = append(sent[kind], team.Slug)
+		}
+	}
+
+	return nil
+}
+
+// followUpTeam takes the next follow-up step for the team if it is due, and returns the kind of step that was taken, if
+// any.
+func (n *Notifier) followUpTeam(ctx context.Context, team naisapi.Team, policy FollowUpPolicy, now time.Time) (ledger.Kind, error) {
+	deliveries, err := n.ledger.List(ctx, team.Slug)
+	if err != nil {
+		return "", err
+	}
+
+	reminder, ok := lastSent(deliveries, ledger.KindReminder, time.Time{})
+	if !ok {
+		return "", nil
+	}
+	remindedAt := reminder.SentAt
+
+	owners, _, err := n.resolveOwners(ctx, team)
+	if err != nil {
+		return "", err
+	}
+
+	answered, err := n.isAcknowledged(ctx, team.Slug, remindedAt, owners)
+	if err != nil || answered {
+		return "", err
+	}
+
+	kind, due, ok := nextEscalation(deliveries, remindedAt, policy.escalations(team, n.adminChannel))
+	if !ok || now.Before(due) {
+		return "", nil
+	}
+
+	var recipients []string
+	switch kind {
+	case ledger.KindFollowUp:
+		recipients = owners
+		if len(recipients) == 0 && team.SlackChannel != "" {
+			recipients = append(recipients, team.SlackChannel)
+		}
+	case ledger.KindTeamChannel:
+		recipients = []string{team.SlackChannel}
+	case ledger.KindAdminChannel:
+		recipients = []string{n.adminChannel}
+	}
+
+	if len(recipients) == 0 {
+		return "", fmt.Errorf("no recipients for %s", kind)
+	}
+
+	msg := getFollowUpMessage(team, n.consoleFrontendURL, kind, remindedAt, n.ackButtons)
+	sent := false
+	for _, recipient := range recipients {
+		log := n.log.WithFields(logrus.Fields{
+			"team_slug":    team.Slug,
+			"recipient_id": recipient,
+			"kind":         kind,
+		})
+		// The follow-up belongs to the period of the reminder, even if it is sent in the next one
+		if err := n.post(ctx, reminder.Period, kind, recipient, msg); err != nil {
+			log.WithError(err).Errorf("post follow-up to Slack")
+			continue
+		}
+		log.Infof("follow-up sent")
+		sent = true
+	}
+
+	if !sent {
+		return "", fmt.Errorf("unable to post %s to any recipient", kind)
+	}
+
+	return kind, nil
+}
+
+// isAcknowledged reports whether one of the owners, given by their Slack user IDs, has answered the notification about
+// the team since the given time. Answers from anyone else, e.g. a member of the Slack channel of the team, are ignored.
+func (n *Notifier) isAcknowledged(ctx context.Context, teamSlug string, since time.Time, owners []string) (bool, error) {
+	list, err := n.acks.List(ctx, teamSlug)
+	if err != nil {
+		return false, err
+	}
+
+	for _, ack := range list {
+		if !ack.At.Before(since) && slices.Contains(owners, ack.UserID) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// nextEscalation returns the first of the escalations that has not been sent since the reminder, and when it is due.
+func nextEscalation(deliveries []ledger.Delivery, remindedAt time.Time, escalations []escalation) (ledger.Kind, time.Time, bool) {
+	previous := remindedAt
+	for _, e := range escalations {
+		delivery, sent := lastSent(deliveries, e.kind, remindedAt)
+		if !sent {
+			return e.kind, previous.Add(e.after), true
+		}
+		previous = delivery.SentAt
+	}
+
+	return "", time.Time{}, false
+}
+
+// lastSent returns the delivery of the given kind that was sent last, ignoring deliveries before since.
+func lastSent(deliveries []ledger.Delivery, kind ledger.Kind, since time.Time) (ledger.Delivery, bool) {
+	var last ledger.Delivery
+	found := false
+	for _, d := range deliveries {
+		if d.Kind != kind || d.SentAt.Before(since) {
+			continue
+		}
+
+		if !found || d.SentAt.After(last.SentAt) {
+			last = d
+			found = true
+		}
+	}
+
+	return last, found
+}
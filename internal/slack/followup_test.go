@@ -0,0 +1,101 @@
+package slack
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nais/slack-teams-notification/internal/acks"
+	"github.com/nais/slack-teams-notification/internal/ledger"
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+)
+
+func TestFollowUp(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeSlack(t, `[{"id": "U1", "name": "owner1", "profile": {"email": "owner1@example.com"}}]`)
+	dir := t.TempDir()
+	deliveries := ledger.NewFileStore(filepath.Join(dir, "ledger.json"))
+	answers := acks.NewFileStore(filepath.Join(dir, "acks.json"))
+
+	now := time.Now()
+	daysAgo := func(days int) time.Time {
+		return now.Add(-time.Duration(days) * 24 * time.Hour)
+	}
+
+	owners := []naisapi.Member{{Name: "Owner 1", Email: "owner1@example.com", Role: "OWNER"}}
+	teams := []naisapi.Team{
+		// Not answered, due for a second reminder
+		{Slug: "team1", Members: owners},
+		// Answered after the reminder
+		{Slug: "team2", Members: owners},
+		// Not answered, but the reminder is recent
+		{Slug: "team3", Members: owners},
+		// Not answered after the second reminder, due for the team channel
+		{Slug: "team4", SlackChannel: "#team4", Members: owners},
+		// Not answered after the team channel, due for the admin channel
+		{Slug: "team5", SlackChannel: "#team5", Members: owners},
+		// Never reminded
+		{Slug: "team6", Members: owners},
+		// Answered by someone in the team channel who is not an owner, due for a second reminder
+		{Slug: "team7", SlackChannel: "#team7", Members: owners},
+	}
+
+	for _, d := range []ledger.Delivery{
+		{Period: "2026-09", TeamSlug: "team1", Kind: ledger.KindReminder, Recipient: "U1", SentAt: daysAgo(10)},
+		{TeamSlug: "team2", Kind: ledger.KindReminder, Recipient: "U1", SentAt: daysAgo(10)},
+		{TeamSlug: "team3", Kind: ledger.KindReminder, Recipient: "U1", SentAt: daysAgo(2)},
+		{TeamSlug: "team4", Kind: ledger.KindReminder, Recipient: "U1", SentAt: daysAgo(20)},
+		{TeamSlug: "team4", Kind: ledger.KindFollowUp, Recipient: "U1", SentAt: daysAgo(13)},
+		{TeamSlug: "team5", Kind: ledger.KindReminder, Recipient: "U1", SentAt: daysAgo(30)},
+		{TeamSlug: "team5", Kind: ledger.KindFollowUp, Recipient: "U1", SentAt: daysAgo(23)},
+		{TeamSlug: "team5", Kind: ledger.KindTeamChannel, Recipient: "#team5", SentAt: daysAgo(20)},
+		{TeamSlug: "team7", Kind: ledger.KindReminder, Recipient: "U1", SentAt: daysAgo(10)},
+	} {
+		if err := deliveries.Record(ctx, d); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	for _, ack := range []acks.Acknowledgement{
+		{TeamSlug: "team2", UserID: "U1", Status: acks.StatusConfirmed, At: daysAgo(9)},
+		{TeamSlug: "team7", UserID: "U2", Status: acks.StatusConfirmed, At: daysAgo(9)},
+	} {
+		if err := answers.Record(ctx, ack); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	policy := FollowUpPolicy{
+		After:         7 * 24 * time.Hour,
+		EscalateAfter: 3 * 24 * time.Hour,
+	}
+
+	n := newTestNotifier(t, f, WithLedger(deliveries), WithAcknowledgements(answers), WithAdminChannel("#admins"))
+	if err := n.FollowUp(ctx, naisapi.Values(teams), policy); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"U1", "#team4", "#admins", "U1"}
+	if channels := f.channels(); !slices.Equal(channels, expected) {
+		t.Fatalf("expected follow-ups to %v, got: %v", expected, channels)
+	}
+
+	// The second reminder is recorded in the period of the reminder it follows up
+	list, err := deliveries.List(ctx, "team1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(list) != 2 || list[1].Kind != ledger.KindFollowUp || list[1].Period != "2026-09" {
+		t.Fatalf("expected a follow-up in period 2026-09, got: %v", list)
+	}
+
+	// Every step is recorded, so running again right away sends nothing
+	if err := n.FollowUp(ctx, naisapi.Values(teams), policy); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if channels := f.channels(); !slices.Equal(channels, expected) {
+		t.Fatalf("expected no new follow-ups, got: %v", channels)
+	}
+}
@@ -0,0 +1,277 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nais/slack-teams-notification/internal/acks"
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/sirupsen/logrus"
+	slackapi "github.com/slack-go/slack"
+)
+
+const (
+	actionMembershipConfirmed    = "membership_confirmed"
+	actionMembershipNeedsChanges = "membership_needs_changes"
+
+	// maxInteractionSize is the largest interaction payload accepted from Slack. The payload includes the original
+	// message, which is at most 50 blocks.
+	maxInteractionSize = 1 << 20
+
+	// updateTimeout is how long the update of an answered message can take.
+	updateTimeout = 10 * time.Second
+)
+
+// ackStatuses maps the action IDs of the acknowledgement buttons to the status they record.
+var ackStatuses = map[string]acks.Status{
+	actionMembershipConfirmed:    acks.StatusConfirmed,
+	actionMembershipNeedsChanges: acks.StatusNeedsChanges,
+}
+
+// acknowledgementActions returns the buttons the owners use to answer the notification about the team. The value of the
+// buttons is the slug of the team, and the block ID is unique per team so a digest can have buttons for several teams.
+func acknowledgementActions(teamSlug string) *slackapi.ActionBlock {
+	return slackapi.NewActionBlock(
+		"acknowledge-"+teamSlug,
+		slackapi.NewButtonBlockElement(
+			actionMembershipConfirmed,
+			teamSlug,
+			slackapi.NewTextBlockObject(slackapi.PlainTextType, "Ser riktig ut", false, false),
+		).WithStyle(slackapi.StylePrimary),
+		slackapi.NewButtonBlockElement(
+			actionMembershipNeedsChanges,
+			teamSlug,
+			slackapi.NewTextBlockObject(slackapi.PlainTextType, "Må endres", false, false),
+		),
+	)
+}
+
+// acknowledgementContext returns the block that replaces the buttons after an owner has answered.
+func acknowledgementContext(ack acks.Acknowledgement) *slackapi.ContextBlock {
+	when := slackDate(ack.At)
+	text := fmt.Sprintf("✅ <@%s> bekreftet at medlemmene i `%s` ser riktige ut %s.", ack.UserID, ack.TeamSlug, when)
+	if ack.Status == acks.StatusNeedsChanges {
+		text = fmt.Sprintf("✏️ <@%s> meldte at `%s` må endres %s.", ack.UserID, ack.TeamSlug, when)
+	}
+
+	return slackapi.NewContextBlock(
+		"",
+		slackapi.NewTextBlockObject(slackapi.MarkdownType, text, false, false),
+	)
+}
+
+// TeamLookup returns the team with the given slug, or false if there is no such team.
+type TeamLookup func(ctx context.Context, teamSlug string) (naisapi.Team, bool, error)
+
+// InteractionHandler handles the interaction requests Slack sends when an owner clicks one of the acknowledgement
+// buttons. The answer is recorded in the store, and the buttons in the original message are replaced with the answer.
+// Since a reminder can end up in the Slack channel of the team, only answers from the owners of the team are recorded.
+type InteractionHandler struct {
+	signingSecret string
+	store         acks.Store
+	notifier      *Notifier
+	teams         TeamLookup
+	httpClient    *http.Client
+	updates       sync.WaitGroup
+	log           logrus.FieldLogger
+}
+
+// NewInteractionHandler returns a handler that looks up the team of each answer with teams, and resolves its owners in
+// Slack with the user directory of notifier. Slack expects a response within 3 seconds, so both must answer without
+// calling the Nais API or Slack, see Notifier.LoadUsers.
+func NewInteractionHandler(signingSecret string, store acks.Store, notifier *Notifier, teams TeamLookup, log logrus.FieldLogger) *InteractionHandler {
+	return &InteractionHandler{
+		signingSecret: signingSecret,
+		store:         store,
+		notifier:      notifier,
+		teams:         teams,
+		httpClient:    &http.Client{Timeout: updateTimeout},
+		log:           log,
+	}
+}
+
+func (h *InteractionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxInteractionSize))
+	if err != nil {
+		http.Error(w, "unable to read request", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.verify(r.Header, body); err != nil {
+		h.log.WithError(err).Warnf("rejecting interaction with invalid signature")
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	var callback slackapi.InteractionCallback
+	if err := json.Unmarshal([]byte(form.Get("payload")), &callback); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	if callback.Type != slackapi.InteractionTypeBlockActions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	answers := make([]answer, 0, len(callback.ActionCallback.BlockActions))
+	for _, action := range callback.ActionCallback.BlockActions {
+		status, ok := ackStatuses[action.ActionID]
+		if !ok {
+			continue
+		}
+
+		ack := acks.Acknowledgement{
+			TeamSlug: action.Value,
+			UserID:   callback.User.ID,
+			UserName: callback.User.Name,
+			Status:   status,
+			At:       time.Now(),
+		}
+
+		log := h.log.WithFields(logrus.Fields{
+			"team_slug": ack.TeamSlug,
+			"user_id":   ack.UserID,
+			"status":    ack.Status,
+		})
+
+		owner, err := h.isOwner(r.Context(), ack.TeamSlug, ack.UserID)
+		if err != nil {
+			log.WithError(err).Errorf("check owners of team")
+			http.Error(w, "unable to check owners of team", http.StatusInternalServerError)
+			return
+		} else if !owner {
+			log.Warnf("rejecting acknowledgement from user who is not an owner of the team")
+			answers = append(answers, answer{ack: ack, rejected: true, log: log})
+			continue
+		}
+
+		if err := h.store.Record(r.Context(), ack); err != nil {
+			log.WithError(err).Errorf("record acknowledgement")
+			http.Error(w, "unable to record acknowledgement", http.StatusInternalServerError)
+			return
+		}
+		log.Infof("acknowledgement recorded")
+		answers = append(answers, answer{blockID: action.BlockID, ack: ack, log: log})
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	// Slack shows an error to the user unless the interaction is acknowledged within 3 seconds, so the message is
+	// updated after the response has been written. The request context is cancelled when the handler returns, so the
+	// update gets its own.
+	for _, a := range answers {
+		h.updates.Add(1)
+		go func() {
+			defer h.updates.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), updateTimeout)
+			defer cancel()
+
+			if a.rejected {
+				if err := h.rejectAnswer(ctx, callback, a.ack); err != nil {
+					a.log.WithError(err).Warnf("tell user that the acknowledgement was rejected")
+				}
+				return
+			}
+
+			// The answer is already recorded, so a failed update of the message is only logged
+			if err := h.updateMessage(ctx, callback, a.blockID, a.ack); err != nil {
+				a.log.WithError(err).Warnf("update message after acknowledgement")
+			}
+		}()
+	}
+}
+
+// Wait waits for the updates of the messages that have already been answered, so they are not lost on shutdown.
+func (h *InteractionHandler) Wait() {
+	h.updates.Wait()
+}
+
+// answer is an acknowledgement whose message is yet to be updated, or that was rejected since the user is not an owner
+// of the team.
+type answer struct {
+	blockID  string
+	ack      acks.Acknowledgement
+	rejected bool
+	log      logrus.FieldLogger
+}
+
+// isOwner reports whether the Slack user is an owner of the team.
+func (h *InteractionHandler) isOwner(ctx context.Context, teamSlug, userID string) (bool, error) {
+	team, ok, err := h.teams(ctx, teamSlug)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	owners, _, err := h.notifier.resolveOwners(ctx, team)
+	if err != nil {
+		return false, err
+	}
+
+	return slices.Contains(owners, userID), nil
+}
+
+// rejectAnswer tells the user who clicked the button, and no one else, that only the owners of the team can answer.
+func (h *InteractionHandler) rejectAnswer(ctx context.Context, callback slackapi.InteractionCallback, ack acks.Acknowledgement) error {
+	if callback.ResponseURL == "" {
+		return fmt.Errorf("interaction has no response URL")
+	}
+
+	return slackapi.PostWebhookCustomHTTPContext(ctx, callback.ResponseURL, h.httpClient, &slackapi.WebhookMessage{
+		Text:         fmt.Sprintf("Bare eierne av `%s` kan svare på påminnelsen.", ack.TeamSlug),
+		ResponseType: slackapi.ResponseTypeEphemeral,
+	})
+}
+
+func (h *InteractionHandler) verify(header http.Header, body []byte) error {
+	verifier, err := slackapi.NewSecretsVerifier(header, h.signingSecret)
+	if err != nil {
+		return err
+	}
+
+	if _, err := verifier.Write(body); err != nil {
+		return err
+	}
+
+	return verifier.Ensure()
+}
+
+// updateMessage replaces the buttons that were clicked with the answer, keeping the rest of the original message.
+func (h *InteractionHandler) updateMessage(ctx context.Context, callback slackapi.InteractionCallback, blockID string, ack acks.Acknowledgement) error {
+	if callback.ResponseURL == "" {
+		return fmt.Errorf("interaction has no response URL")
+	}
+
+	blocks := make([]slackapi.Block, 0, len(callback.Message.Blocks.BlockSet))
+	for _, block := range callback.Message.Blocks.BlockSet {
+		if block.ID() == blockID {
+			blocks = append(blocks, acknowledgementContext(ack))
+			continue
+		}
+		blocks = append(blocks, block)
+	}
+
+	return slackapi.PostWebhookCustomHTTPContext(ctx, callback.ResponseURL, h.httpClient, &slackapi.WebhookMessage{
+		Text:            callback.Message.Text,
+		Blocks:          &slackapi.Blocks{BlockSet: blocks},
+		ReplaceOriginal: true,
+	})
+}
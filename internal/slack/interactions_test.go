@@ -0,0 +1,158 @@
+package slack
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nais/slack-teams-notification/internal/acks"
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/nais/slack-teams-notification/internal/review"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+)
+
+const testSigningSecret = "signing-secret"
+
+func signedInteraction(t *testing.T, secret string, payload any) *http.Request {
+	t.Helper()
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("encode payload: %v", err)
+	}
+
+	body := url.Values{"payload": {string(data)}}.Encode()
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte("v0:" + timestamp + ":" + body))
+
+	r := httptest.NewRequest(http.MethodPost, "/slack/interactions", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	r.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return r
+}
+
+func TestInteractionHandler(t *testing.T) {
+	team := naisapi.Team{
+		Slug:    "team1",
+		Members: []naisapi.Member{{Name: "Owner 1", Email: "owner1@example.com", Role: "OWNER"}},
+	}
+	msg := getNotificationMessage(review.New(team, "https://console.example.com"), nil, true)
+
+	// The response URL blocks until released, so the test fails if the handler waits for the update before responding
+	release := make(chan struct{})
+	updates := make(chan string, 1)
+	responseServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		body, _ := io.ReadAll(r.Body)
+		updates <- string(body)
+	}))
+	t.Cleanup(responseServer.Close)
+
+	payload := map[string]any{
+		"type":         "block_actions",
+		"user":         map[string]any{"id": "U1", "name": "owner1"},
+		"response_url": responseServer.URL,
+		"message":      map[string]any{"text": msg.text, "blocks": msg.blocks},
+		"actions": []map[string]any{
+			{"type": "button", "action_id": actionMembershipConfirmed, "block_id": "acknowledge-team1", "value": "team1"},
+		},
+	}
+
+	f := newFakeSlack(t, `[
+		{"id": "U1", "name": "owner1", "profile": {"email": "owner1@example.com"}},
+		{"id": "U2", "name": "member1", "profile": {"email": "member1@example.com"}}
+	]`)
+	teams := func(_ context.Context, teamSlug string) (naisapi.Team, bool, error) {
+		return team, teamSlug == team.Slug, nil
+	}
+
+	newHandler := func(t *testing.T) (*InteractionHandler, acks.Store) {
+		log, _ := logrustest.NewNullLogger()
+		store := acks.NewFileStore(filepath.Join(t.TempDir(), "acks.json"))
+		return NewInteractionHandler(testSigningSecret, store, newTestNotifier(t, f), teams, log), store
+	}
+
+	t.Run("records acknowledgement and updates message", func(t *testing.T) {
+		handler, store := newHandler(t)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, signedInteraction(t, testSigningSecret, payload))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got: %d", w.Code)
+		}
+
+		list, err := store.List(t.Context(), "team1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(list) != 1 || list[0].UserID != "U1" || list[0].Status != acks.StatusConfirmed {
+			t.Fatalf("unexpected acknowledgements: %v", list)
+		}
+
+		close(release)
+		update := <-updates
+		if !strings.Contains(update, `"replace_original":true`) {
+			t.Errorf("expected the original message to be replaced, got: %s", update)
+		}
+
+		if strings.Contains(update, actionMembershipConfirmed) {
+			t.Errorf("expected the buttons to be removed, got: %s", update)
+		}
+
+		if !strings.Contains(update, `\u003c@U1\u003e bekreftet`) {
+			t.Errorf("expected the message to show the confirmation, got: %s", update)
+		}
+	})
+
+	t.Run("rejects answer from non-owner", func(t *testing.T) {
+		handler, store := newHandler(t)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, signedInteraction(t, testSigningSecret, map[string]any{
+			"type":         "block_actions",
+			"user":         map[string]any{"id": "U2", "name": "member1"},
+			"response_url": responseServer.URL,
+			"message":      map[string]any{"text": msg.text, "blocks": msg.blocks},
+			"actions":      payload["actions"],
+		}))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got: %d", w.Code)
+		}
+
+		if list, _ := store.List(t.Context(), "team1"); len(list) != 0 {
+			t.Fatalf("expected no acknowledgements, got: %v", list)
+		}
+
+		update := <-updates
+		if !strings.Contains(update, `"response_type":"ephemeral"`) || !strings.Contains(update, `"replace_original":false`) {
+			t.Errorf("expected an ephemeral reply that keeps the original message, got: %s", update)
+		}
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		handler, store := newHandler(t)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, signedInteraction(t, "wrong-secret", payload))
+
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("expected status 401, got: %d", w.Code)
+		}
+
+		if list, _ := store.List(t.Context(), "team1"); len(list) != 0 {
+			t.Fatalf("expected no acknowledgements, got: %v", list)
+		}
+	})
+}
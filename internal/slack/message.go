@@ -3,21 +3,33 @@ package slack
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/nais/slack-teams-notification/internal/ledger"
 	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/nais/slack-teams-notification/internal/review"
 	slackapi "github.com/slack-go/slack"
 )
 
 func list(entries []string) *slackapi.RichTextBlock {
+	return slackapi.NewRichTextBlock(
+		uuid.NewString(),
+		slackapi.NewRichTextList(slackapi.RTEListBullet, 0, listItems(entries)...),
+	)
+}
+
+func listItems(entries []string) []slackapi.RichTextElement {
 	elements := make([]slackapi.RichTextElement, len(entries))
 	for i, entry := range entries {
 		elements[i] = slackapi.NewRichTextSection(slackapi.NewRichTextSectionTextElement(entry, nil))
 	}
+	return elements
+}
 
-	return slackapi.NewRichTextBlock(
-		uuid.NewString(),
-		slackapi.NewRichTextList(slackapi.RTEListBullet, 0, elements...),
+func bold(text string) *slackapi.RichTextSection {
+	return slackapi.NewRichTextSection(
+		slackapi.NewRichTextSectionTextElement(text, &slackapi.RichTextSectionTextStyle{Bold: true}),
 	)
 }
 
@@ -45,50 +57,286 @@ func header(format string, args ...any) *slackapi.HeaderBlock {
 	)
 }
 
-func getNotificationMessageOptions(team naisapi.Team, frontendURL string) []slackapi.MsgOption {
-	blocks := []slackapi.Block{
-		mrkdwn("👋 Hei %s!", team.Slug),
-		mrkdwn("Dere er ansvarlige for å holde teamets medlemsliste oppdatert. Siden medlemskap i Nais-team gir utvidede rettigheter til blant annet produksjonsmiljø og persondata, er det viktig å holde teamet oppdatert."),
-		mrkdwn("Følgende brukere er i dag registrert som medlemmer og eiere i `%s`:", team.Slug),
-	}
+// message is a rendered Slack message.
+type message struct {
+	// text is the fallback text used in notifications
+	text   string
+	blocks []slackapi.Block
 
-	memberNames := make([]string, 0)
-	ownerNames := make([]string, 0)
-	for _, member := range team.Members {
-		name := member.Name
-		if member.IsOwner() {
-			ownerNames = append(ownerNames, name)
-		}
-		memberNames = append(memberNames, name)
+	// teamSlugs are the teams the message is about
+	teamSlugs []string
+}
+
+func (m message) options() []slackapi.MsgOption {
+	return []slackapi.MsgOption{
+		slackapi.MsgOptionBlocks(m.blocks...),
+		slackapi.MsgOptionText(m.text, false),
 	}
+}
 
-	blocks = append(blocks, header("Medlemmer"), list(memberNames))
+// getNotificationMessage returns the review sent to the owners of a team as a message. If ackButtons is set, the
+// message ends with buttons the owners can use to answer whether the members are correct.
+func getNotificationMessage(r review.Review, unresolvedOwners []naisapi.Member, ackButtons bool) message {
+	blocks := []slackapi.Block{
+		mrkdwn("👋 %s", r.Greeting()),
+	}
 
-	if len(ownerNames) > 0 {
-		blocks = append(blocks, header("Eiere"), list(ownerNames))
+	if r.Purpose != "" {
+		blocks = append(blocks, mrkdwn("*Formål:* %s", r.Purpose))
 	}
 
 	blocks = append(
 		blocks,
+		mrkdwn("%s", review.Responsibility),
+		mrkdwn("%s", r.MembersIntro()),
+		header("Medlemmer"),
+		list(r.Members),
+	)
+
+	if len(r.Owners) > 0 {
+		blocks = append(blocks, header("Eiere"), list(r.Owners))
+	}
+
+	if len(r.Grants) > 0 {
+		blocks = append(blocks, header("Tilganger"), mrkdwn("%s", r.GrantsIntro()), list(r.Grants))
+	}
+
+	blocks = append(blocks, mrkdwn("%s", review.Question(fmt.Sprintf("<%s|Console>.", r.ConsoleURL))))
+
+	if r.OwnerWarning != "" {
+		blocks = append(blocks, mrkdwn("*NB!* %s", r.OwnerWarning))
+	}
+
+	if warning := unresolvedOwnersWarning(unresolvedOwners); warning != "" {
+		blocks = append(blocks, mrkdwn("%s", warning))
+	}
+
+	if ackButtons {
+		blocks = append(blocks, acknowledgementActions(r.TeamSlug))
+	}
+
+	return message{
+		text:      r.Subject,
+		blocks:    blocks,
+		teamSlugs: []string{r.TeamSlug},
+	}
+}
+
+// getFollowUpMessage returns the message sent when no one has answered the reminder about the team that was sent at
+// remindedAt. The owners and the Slack channel of the team get the reminder again with an introduction, while the admin
+// channel gets a short summary.
+func getFollowUpMessage(team naisapi.Team, frontendURL string, kind ledger.Kind, remindedAt time.Time, ackButtons bool) message {
+	switch kind {
+	case ledger.KindAdminChannel:
+		r := review.New(team, frontendURL)
+		blocks := []slackapi.Block{
+			mrkdwn(
+				"🚨 Ingen av eierne av `%s` har svart på påminnelsen om å se over medlemmene, som ble sendt %s, selv etter flere purringer.",
+				team.Slug,
+				slackDate(remindedAt),
+			),
+		}
+
+		if len(r.Owners) > 0 {
+			blocks = append(blocks, header("Eiere"), list(r.Owners))
+		}
+
+		blocks = append(blocks, mrkdwn("Teamet kan administreres i <%s|Console>.", r.ConsoleURL))
+		return message{
+			text:      fmt.Sprintf("Eierne av %q-teamet har ikke svart på påminnelsen", team.Slug),
+			blocks:    blocks,
+			teamSlugs: []string{team.Slug},
+		}
+	case ledger.KindTeamChannel:
+		msg := getNotificationMessage(review.New(team, frontendURL), nil, ackButtons)
+		msg.text = fmt.Sprintf("Eierne av %q-teamet har ikke svart på påminnelsen", team.Slug)
+		msg.blocks = append([]slackapi.Block{
+			mrkdwn(
+				"⚠️ Ingen av eierne av `%s` har svart på påminnelsen om å se over medlemmene, som ble sendt %s, selv etter en ny påminnelse. Kan noen i teamet sørge for at medlemslisten blir sett over?",
+				team.Slug,
+				slackDate(remindedAt),
+			),
+		}, msg.blocks...)
+		return msg
+	default:
+		msg := getNotificationMessage(review.New(team, frontendURL), nil, ackButtons)
+		msg.text = fmt.Sprintf("Ny påminnelse om å holde %q-teamet oppdatert", team.Slug)
+		msg.blocks = append([]slackapi.Block{
+			mrkdwn("⏰ Vi har ikke fått svar på påminnelsen om `%s` som ble sendt %s.", team.Slug, slackDate(remindedAt)),
+		}, msg.blocks...)
+		return msg
+	}
+}
+
+// getSummaryMessage returns the summary of a run posted to the admin channel.
+func getSummaryMessage(result *Result) message {
+	blocks := []slackapi.Block{
+		header("Oppsummering av påminnelser"),
 		mrkdwn(
-			"Ser dette korrekt ut? Om ikke kan dere administrere teamet i <%s|Console>.",
-			getTeamMembersAdminURL(frontendURL, team.Slug),
+			"*%d* team ble varslet, *%d* feilet og *%d* ble hoppet over.",
+			len(result.Notified),
+			len(result.Failed),
+			result.Skipped(),
 		),
-	)
+	}
 
-	if len(ownerNames) == 0 {
-		blocks = append(blocks, mrkdwn("*NB!* Teamet har ingen eier, ta kontakt med Nais-teamet på #utviklerrommet for å få lagt inn en eier."))
-	} else if len(ownerNames) < 2 {
-		blocks = append(blocks, mrkdwn("*NB!* Det *bør* være minst to eiere av hvert team."))
+	for _, field := range []struct {
+		title string
+		items []string
+	}{
+		{title: "Team som ikke kunne varsles", items: result.Failed},
+		{title: "Team uten medlemmer", items: result.NoMembers},
+		{title: "Team uten eiere", items: result.NoOwners},
+		{title: "Team varslet i teamets Slack-kanal", items: result.ChannelFallback},
+		{title: "Team varslet på e-post eller webhook", items: result.Routed},
+		{title: "Eiere som ikke ble funnet i Slack", items: result.UnresolvedOwners},
+	} {
+		if len(field.items) > 0 {
+			blocks = append(blocks, mrkdwn("*%s (%d):* %s", field.title, len(field.items), summaryList(field.items)))
+		}
 	}
 
-	return []slackapi.MsgOption{
-		slackapi.MsgOptionBlocks(blocks...),
-		slackapi.MsgOptionText(fmt.Sprintf("Påminnelse om å holde %q-teamet oppdatert", team.Slug), false),
+	return message{
+		text:   fmt.Sprintf("%d Nais-team ble varslet om å holde medlemslisten oppdatert", len(result.Notified)),
+		blocks: blocks,
 	}
 }
 
-func getTeamMembersAdminURL(baseURL, teamSlug string) string {
-	baseURL = strings.TrimSuffix(baseURL, "/")
-	return fmt.Sprintf("%s/team/%s/members", baseURL, teamSlug)
+// maxSummaryListLength is the maximum length of a list in the summary, which keeps the section below the limit of 3000
+// characters Slack sets for the text of a section.
+const maxSummaryListLength = 2500
+
+// summaryList returns the items as a comma-separated list, shortened to the items that fit in a section.
+func summaryList(items []string) string {
+	text := ""
+	for i, item := range items {
+		next := "`" + item + "`"
+		if i > 0 {
+			next = ", " + next
+		}
+
+		if len(text)+len(next) > maxSummaryListLength {
+			return fmt.Sprintf("%s og %d til", text, len(items)-i)
+		}
+		text += next
+	}
+	return text
+}
+
+// getDigestMessages returns the messages sent to an owner of several teams, with a section for each team. Since
+// Slack limits the number of blocks in a message, the digest is split into messages of at most maxBlocks blocks. If
+// ackButtons is set, each team gets buttons the owner can use to answer whether the members are correct.
+func getDigestMessages(ownerName string, teams []naisapi.Team, frontendURL string, unresolvedOwners map[string][]naisapi.Member, ackButtons bool, maxBlocks int) []message {
+	intro := []slackapi.Block{
+		mrkdwn("👋 Hei %s!", ownerName),
+		mrkdwn("Du er eier av %d Nais-team, og er sammen med de andre eierne ansvarlig for å holde medlemslistene oppdatert. Siden medlemskap i Nais-team gir utvidede rettigheter til blant annet produksjonsmiljø og persondata, er det viktig å holde teamene oppdatert.", len(teams)),
+		mrkdwn("Under finner du medlemmene og eierne som i dag er registrert i hvert av teamene dine."),
+	}
+
+	sections := make([][]slackapi.Block, 0, len(teams))
+	for _, team := range teams {
+		sections = append(sections, digestTeamBlocks(team, frontendURL, unresolvedOwners[team.Slug], ackButtons))
+	}
+
+	messages := make([]message, 0)
+	blocks := intro
+	slugs := make([]string, 0)
+	for i, section := range sections {
+		if len(blocks)+len(section) > maxBlocks {
+			messages = append(messages, digestMessage(blocks, len(teams), slugs))
+			blocks = make([]slackapi.Block, 0)
+			slugs = make([]string, 0)
+		}
+		blocks = append(blocks, section...)
+		slugs = append(slugs, teams[i].Slug)
+	}
+
+	return append(messages, digestMessage(blocks, len(teams), slugs))
+}
+
+// maxBlocksPerMessage is the maximum number of blocks Slack accepts in a single message.
+const maxBlocksPerMessage = 50
+
+// digestMessage returns a single message of a digest, with the sections of the teams in teamSlugs.
+func digestMessage(blocks []slackapi.Block, teamCount int, teamSlugs []string) message {
+	return message{
+		text:      fmt.Sprintf("Påminnelse om å holde dine %d Nais-team oppdatert", teamCount),
+		blocks:    blocks,
+		teamSlugs: teamSlugs,
+	}
+}
+
+// digestTeamBlocks returns a compact section for a single team in a digest message.
+func digestTeamBlocks(team naisapi.Team, frontendURL string, unresolvedOwners []naisapi.Member, ackButtons bool) []slackapi.Block {
+	r := review.New(team, frontendURL)
+	blocks := []slackapi.Block{
+		slackapi.NewDividerBlock(),
+		header("%s", team.Slug),
+	}
+
+	if r.Purpose != "" {
+		blocks = append(blocks, mrkdwn("*Formål:* %s", r.Purpose))
+	}
+
+	elements := []slackapi.RichTextElement{
+		bold("Medlemmer"),
+		slackapi.NewRichTextList(slackapi.RTEListBullet, 0, listItems(r.Members)...),
+	}
+
+	if len(r.Owners) > 0 {
+		elements = append(
+			elements,
+			bold("Eiere"),
+			slackapi.NewRichTextList(slackapi.RTEListBullet, 0, listItems(r.Owners)...),
+		)
+	}
+
+	if len(r.Grants) > 0 {
+		elements = append(
+			elements,
+			bold("Tilganger"),
+			slackapi.NewRichTextList(slackapi.RTEListBullet, 0, listItems(r.Grants)...),
+		)
+	}
+
+	blocks = append(blocks, slackapi.NewRichTextBlock(uuid.NewString(), elements...))
+
+	text := fmt.Sprintf("Ser dette korrekt ut? Om ikke kan du administrere teamet i <%s|Console>.", r.ConsoleURL)
+	if r.OwnerWarning != "" {
+		text += "\n*NB!* " + r.OwnerWarning
+	}
+
+	if warning := unresolvedOwnersWarning(unresolvedOwners); warning != "" {
+		text += "\n" + warning
+	}
+
+	blocks = append(blocks, mrkdwn("%s", text))
+	if ackButtons {
+		blocks = append(blocks, acknowledgementActions(team.Slug))
+	}
+
+	return blocks
+}
+
+// unresolvedOwnersWarning returns a warning to include in the message if some of the owners could not be found in
+// Slack, and therefore have not received the message.
+func unresolvedOwnersWarning(unresolvedOwners []naisapi.Member) string {
+	if len(unresolvedOwners) == 0 {
+		return ""
+	}
+
+	names := make([]string, len(unresolvedOwners))
+	for i, member := range unresolvedOwners {
+		names[i] = member.Name
+	}
+
+	return fmt.Sprintf(
+		"*NB!* Følgende eiere av teamet ble ikke funnet i Slack og har ikke fått denne påminnelsen: %s. Har de sluttet, bør de fjernes fra teamet.",
+		strings.Join(names, ", "),
+	)
+}
+
+// slackDate formats the time as a date that Slack shows in the time zone of the reader.
+func slackDate(t time.Time) string {
+	return fmt.Sprintf("<!date^%d^{date_short_pretty} kl. {time}|%s>", t.Unix(), t.UTC().Format(time.RFC3339))
 }
@@ -2,80 +2,426 @@ package slack
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"slices"
 	"time"
 
+	"github.com/nais/slack-teams-notification/internal/acks"
+	"github.com/nais/slack-teams-notification/internal/ledger"
 	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/nais/slack-teams-notification/internal/review"
 	"github.com/sirupsen/logrus"
 	slackapi "github.com/slack-go/slack"
 )
 
 type Notifier struct {
 	consoleFrontendURL string
+	slackToken         string
 	slackApi           *slackapi.Client
+	httpClient         *http.Client
+	limiter            *rateLimiter
+	poster             poster
+	users              *userDirectory
+	digest             bool
+	userCachePath      string
+	userCacheTTL       time.Duration
+	dryRunOutput       io.Writer
+	slackAPIURL        string
+	redirectRecipient  string
+	ackButtons         bool
+	adminChannel       string
+	ledger             ledger.Store
+	period             string
+	acks               acks.Store
 	log                logrus.FieldLogger
 }
 
+type NotifierOption func(*Notifier)
+
+// WithDigest makes the notifier send a single message to each owner, covering all the teams they own, instead of one
+// message per team.
+func WithDigest() NotifierOption {
+	return func(n *Notifier) {
+		n.digest = true
+	}
+}
+
+// WithUserCache makes the notifier cache the Slack user directory in a file at path, which is reused by later runs
+// as long as it is younger than ttl.
+func WithUserCache(path string, ttl time.Duration) NotifierOption {
+	return func(n *Notifier) {
+		n.userCachePath = path
+		n.userCacheTTL = ttl
+	}
+}
+
+// WithDryRun makes the notifier write every message it would have posted to w as a line of JSON, including the
+// recipient, the teams and the Block Kit blocks, instead of posting it to Slack.
+func WithDryRun(w io.Writer) NotifierOption {
+	return func(n *Notifier) {
+		n.dryRunOutput = w
+	}
+}
+
+// WithRedirect makes the notifier send every message to recipient, which is a Slack user ID or channel, instead of
+// the owners and channels of the teams. Each message says who would have received it.
+func WithRedirect(recipient string) NotifierOption {
+	return func(n *Notifier) {
+		n.redirectRecipient = recipient
+	}
+}
+
+// WithAcknowledgementButtons adds buttons to the messages where the owners can confirm that the members of the team
+// are correct, or report that the team needs changes. The answers are handled by an InteractionHandler, which must be
+// configured as the interactivity request URL of the Slack app.
+func WithAcknowledgementButtons() NotifierOption {
+	return func(n *Notifier) {
+		n.ackButtons = true
+	}
+}
+
+// WithAdminChannel makes the notifier post a summary of each run to the channel. The channel is also the last step
+// when following up teams that have not answered.
+func WithAdminChannel(channel string) NotifierOption {
+	return func(n *Notifier) {
+		n.adminChannel = channel
+	}
+}
+
+// WithLedger makes the notifier record every delivered message in the ledger, which is used to follow up on teams that
+// have not answered.
+func WithLedger(store ledger.Store) NotifierOption {
+	return func(n *Notifier) {
+		n.ledger = store
+	}
+}
+
+// WithPeriod sets the run period the deliveries are recorded in. Deliveries that are already in the ledger for the
+// period are skipped, so a run can be retried without notifying anyone twice. Defaults to the current month.
+func WithPeriod(period string) NotifierOption {
+	return func(n *Notifier) {
+		n.period = period
+	}
+}
+
+// WithSlackAPIURL makes the notifier use a different URL for the Slack API, e.g. a test server. The URL must end with a
+// slash.
+func WithSlackAPIURL(url string) NotifierOption {
+	return func(n *Notifier) {
+		n.slackAPIURL = url
+	}
+}
+
 // NewNotifier Create a new Slack notifier instance
-func NewNotifier(slackApiToken, consoleFrontendURL string, log logrus.FieldLogger) *Notifier {
-	return &Notifier{
+func NewNotifier(slackApiToken, consoleFrontendURL string, log logrus.FieldLogger, opts ...NotifierOption) *Notifier {
+	n := &Notifier{
 		log:                log,
 		consoleFrontendURL: consoleFrontendURL,
-		slackApi:           slackapi.New(slackApiToken),
+		slackToken:         slackApiToken,
+		httpClient:         &http.Client{Timeout: 10 * time.Second},
+		limiter:            newRateLimiter(log.WithField("component", "slack-rate-limiter")),
+		period:             ledger.PeriodOf(time.Now()),
+		userCacheTTL:       defaultUserCacheTTL,
+	}
+
+	for _, opt := range opts {
+		opt(n)
+	}
+
+	slackOpts := make([]slackapi.Option, 0)
+	if n.slackAPIURL != "" {
+		slackOpts = append(slackOpts, slackapi.OptionAPIURL(n.slackAPIURL))
 	}
+	n.slackApi = slackapi.New(slackApiToken, slackOpts...)
+
+	n.poster = &slackPoster{api: n.slackApi, limiter: n.limiter}
+	if n.dryRunOutput != nil {
+		n.poster = &dryRunPoster{w: n.dryRunOutput}
+	}
+
+	if n.redirectRecipient != "" {
+		n.poster = &redirectPoster{next: n.poster, recipient: n.redirectRecipient}
+	}
+
+	n.users = newUserDirectory(n.slackApi, n.limiter, n.userCachePath, n.userCacheTTL, log.WithField("component", "slack-user-directory"))
+	return n
 }
 
-// NotifyTeams Notify all teams on Slack that they need to keep their teams up to date
-func (n *Notifier) NotifyTeams(ctx context.Context, teams []naisapi.Team) {
-	for _, team := range teams {
+// NotifyTeams Notify all teams on Slack that they need to keep their teams up to date. Teams are notified as they are
+// yielded, and an error from the iterator or a fatal error from Slack stops the notification and is returned along
+// with the result so far. In digest mode, all teams are collected before the owners are notified.
+func (n *Notifier) NotifyTeams(ctx context.Context, teams iter.Seq2[naisapi.Team, error]) (*Result, error) {
+	result := &Result{}
+
+	digestTeams := make([]naisapi.Team, 0)
+
+	for team, err := range teams {
+		if err != nil {
+			return result, err
+		}
+
+		if team.IsBeingDeleted() {
+			n.log.
+				WithField("team_slug", team.Slug).
+				Infof("team is being deleted, skip notification")
+			result.BeingDeleted = append(result.BeingDeleted, team.Slug)
+			continue
+		}
+
 		if len(team.Members) == 0 {
 			n.log.
 				WithField("team_slug", team.Slug).
 				Infof("no members in team, skip notification")
+			result.NoMembers = append(result.NoMembers, team.Slug)
+			continue
+		}
+
+		if n.digest {
+			digestTeams = append(digestTeams, team)
 			continue
 		}
 
-		if err := n.notifyTeam(ctx, team); err != nil {
+		if err := n.notifyTeam(ctx, team, review.New(team, n.consoleFrontendURL), result); errors.Is(err, ledger.ErrAlreadyDelivered) {
+			result.AlreadyNotified = append(result.AlreadyNotified, team.Slug)
+			continue
+		} else if err != nil {
 			n.log.
 				WithError(err).
 				WithField("team_slug", team.Slug).
 				WithField("slack_channel", team.SlackChannel).
 				Errorf("posting message to Slack")
+			result.Failed = append(result.Failed, team.Slug)
+
+			if err := n.limiter.fatalError(); err != nil {
+				return result, err
+			}
+			continue
 		}
+
+		result.Notified = append(result.Notified, team.Slug)
 	}
-}
 
-func (n *Notifier) notifyTeam(ctx context.Context, team naisapi.Team) error {
-	msgOptions := getNotificationMessageOptions(team, n.consoleFrontendURL)
-	var recipients []string
-	owners := n.ownersOf(team)
-	for _, member := range owners {
-		slackUser, err := n.slackApi.GetUserByEmailContext(ctx, member.Email)
-		if err != nil {
-			return err
+	if n.digest {
+		if err := n.notifyOwners(ctx, digestTeams, result); err != nil {
+			return result, err
 		}
-		recipients = append(recipients, slackUser.ID)
 	}
+
+	// A TeamNotifier may have run into a fatal error while the teams were routed
+	if err := n.limiter.fatalError(); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// TeamNotifier notifies one team at a time in Slack, so Slack can be one of the channels of a notify.Router. The
+// teams are never collected in a digest.
+type TeamNotifier struct {
+	notifier *Notifier
+	result   *Result
+}
+
+// TeamNotifier returns a TeamNotifier that adds what happened to the teams to result. Only the details are added, such
+// as the owners that could not be found in Slack, since the router keeps track of which teams were notified.
+func (n *Notifier) TeamNotifier(result *Result) *TeamNotifier {
+	return &TeamNotifier{notifier: n, result: result}
+}
+
+// Notify sends the review to the owners of the team, or to the Slack channel of the team if none of the owners can be
+// found in Slack. An error wrapping ledger.ErrAlreadyDelivered is returned if all of them have already been notified in
+// the period.
+func (t *TeamNotifier) Notify(ctx context.Context, team naisapi.Team, r review.Review) error {
+	if err := t.notifier.limiter.fatalError(); err != nil {
+		return err
+	}
+
+	return t.notifier.notifyTeam(ctx, team, r, t.result)
+}
+
+// notifyTeam sends the review to the owners of the team, or the Slack channel of the team if none of the owners can be
+// found in Slack. An error is returned unless at least one recipient has been notified, or ledger.ErrAlreadyDelivered
+// if none were notified now, since all of them had been notified earlier in the period.
+func (n *Notifier) notifyTeam(ctx context.Context, team naisapi.Team, r review.Review, result *Result) error {
+	if !slices.ContainsFunc(team.Members, naisapi.Member.IsOwner) {
+		result.NoOwners = append(result.NoOwners, team.Slug)
+	}
+
+	recipients, unresolvedOwners, err := n.resolveOwners(ctx, team)
+	if err != nil {
+		result.addError("", []string{team.Slug}, err)
+		return err
+	}
+
+	for _, member := range unresolvedOwners {
+		result.addUnresolvedOwner(member.Email)
+	}
+
 	if len(recipients) == 0 {
+		if team.SlackChannel == "" {
+			err := fmt.Errorf("no owners found in Slack and the team has no Slack channel")
+			result.addError("", []string{team.Slug}, err)
+			return err
+		}
 		recipients = append(recipients, team.SlackChannel)
+		result.ChannelFallback = append(result.ChannelFallback, team.Slug)
 	}
+
+	msg := getNotificationMessage(r, unresolvedOwners, n.ackButtons)
+	sent, skipped, failed := 0, 0, 0
 	for _, recipient := range recipients {
 		log := n.log.WithFields(logrus.Fields{
 			"team_slug":    team.Slug,
 			"recipient_id": recipient,
 		})
-		_, _, err := n.slackApi.PostMessageContext(ctx, recipient, msgOptions...)
+
+		delivered, err := n.delivered(ctx, team.Slug, recipient)
 		if err != nil {
+			log.WithError(err).Errorf("check ledger")
+			result.addError(recipient, []string{team.Slug}, err)
+			failed++
+			continue
+		} else if delivered {
+			log.Infof("already notified in this period, skip notification")
+			skipped++
+			continue
+		}
+
+		if err := n.post(ctx, n.period, ledger.KindReminder, recipient, msg); err != nil {
+			log.WithError(err).Errorf("post message to Slack")
+			result.addError(recipient, []string{team.Slug}, err)
+			failed++
+			continue
+		}
+		log.Infof("notification sent")
+		sent++
+	}
+
+	if sent == 0 && skipped > 0 && failed == 0 {
+		return ledger.ErrAlreadyDelivered
+	} else if sent == 0 {
+		return fmt.Errorf("unable to post message to any of the %d recipients", failed)
+	}
+
+	return nil
+}
+
+// LoadUsers loads the Slack user directory, so the first owner that is looked up does not have to wait for it. Once
+// loaded, the directory is kept up to date in the background.
+func (n *Notifier) LoadUsers(ctx context.Context) error {
+	if _, err := n.users.index(ctx); err != nil {
+		return fmt.Errorf("load Slack user directory: %w", err)
+	}
+	return nil
+}
+
+// resolveOwners resolves each owner of the team to a Slack user ID. Owners that cannot be found in Slack, e.g. because
+// they have left, are returned separately so the remaining owners can still be notified. Any other error is returned.
+func (n *Notifier) resolveOwners(ctx context.Context, team naisapi.Team) (recipients []string, unresolved []naisapi.Member, err error) {
+	for _, member := range n.ownersOf(team) {
+		slackUser, err := n.users.lookup(ctx, member.Email)
+		if errors.Is(err, ErrUserNotFound) {
+			n.log.
+				WithField("team_slug", team.Slug).
+				WithField("owner_email", member.Email).
+				Warnf("unable to find team owner in Slack")
+			unresolved = append(unresolved, member)
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+		recipients = append(recipients, slackUser.ID)
+	}
+
+	return recipients, unresolved, nil
+}
+
+// postMessages posts the reminders to the recipient in order, and reports whether all of them were sent.
+func (n *Notifier) postMessages(ctx context.Context, recipient string, messages []message, result *Result, log logrus.FieldLogger) bool {
+	log = log.WithField("recipient_id", recipient)
+	for _, msg := range messages {
+		if err := n.post(ctx, n.period, ledger.KindReminder, recipient, msg); err != nil {
 			log.WithError(err).Errorf("post message to Slack")
-		} else {
-			log.Infof("notification sent")
+			result.addError(recipient, msg.teamSlugs, err)
+			return false
+		}
+	}
+
+	log.Infof("notification sent")
+	return true
+}
+
+// post posts the message to the recipient and records the delivery of each of the teams in the message in the ledger
+// under the period. Since the message has already been sent, a failure to record it is only logged.
+func (n *Notifier) post(ctx context.Context, period string, kind ledger.Kind, recipient string, msg message) error {
+	posted, err := n.poster.post(ctx, recipient, msg)
+	if err != nil {
+		return err
+	}
+
+	if !n.usesLedger() {
+		return nil
+	}
+
+	sentAt := time.Now()
+	deliveries := make([]ledger.Delivery, len(msg.teamSlugs))
+	for i, teamSlug := range msg.teamSlugs {
+		deliveries[i] = ledger.Delivery{
+			Period:    period,
+			TeamSlug:  teamSlug,
+			Kind:      kind,
+			Recipient: recipient,
+			Channel:   posted.channel,
+			Timestamp: posted.timestamp,
+			SentAt:    sentAt,
 		}
+	}
 
-		time.Sleep(time.Second) // Sleep due to strict rate limiting
+	if err := n.ledger.Record(ctx, deliveries...); err != nil {
+		n.log.
+			WithError(err).
+			WithField("recipient_id", recipient).
+			WithField("team_slugs", msg.teamSlugs).
+			Errorf("record delivery in ledger")
 	}
 
 	return nil
 }
 
+// PostSummary posts a summary of the run to the admin channel, if any. A failure is only logged, since the teams have
+// already been notified.
+func (n *Notifier) PostSummary(ctx context.Context, result *Result) {
+	if n.adminChannel == "" {
+		return
+	}
+
+	log := n.log.WithField("recipient_id", n.adminChannel)
+	if _, err := n.poster.post(ctx, n.adminChannel, getSummaryMessage(result)); err != nil {
+		log.WithError(err).Errorf("post run summary to Slack")
+		return
+	}
+	log.Infof("run summary sent")
+}
+
+// usesLedger reports whether deliveries are looked up in and recorded in the ledger. Nothing reaches the real
+// recipients in a dry run or when redirecting, so the ledger is left alone, and every message is sent.
+func (n *Notifier) usesLedger() bool {
+	return n.ledger != nil && n.dryRunOutput == nil && n.redirectRecipient == ""
+}
+
+// delivered reports whether the recipient has already been reminded about the team in this period.
+func (n *Notifier) delivered(ctx context.Context, teamSlug, recipient string) (bool, error) {
+	if !n.usesLedger() {
+		return false, nil
+	}
+
+	return n.ledger.Delivered(ctx, ledger.Key{Period: n.period, TeamSlug: teamSlug, Recipient: recipient})
+}
+
 func (n *Notifier) ownersOf(team naisapi.Team) []naisapi.Member {
 	owners := make([]naisapi.Member, 0)
 	for _, member := range team.Members {
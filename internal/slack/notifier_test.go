@@ -0,0 +1,593 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nais/slack-teams-notification/internal/ledger"
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/nais/slack-teams-notification/internal/review"
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	slackapi "github.com/slack-go/slack"
+)
+
+// fakeSlack is a minimal Slack API that knows a fixed set of users and records the messages posted to it.
+type fakeSlack struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	posts    []fakePost
+	handlers map[string]http.HandlerFunc
+}
+
+type fakePost struct {
+	channel string
+	blocks  string
+}
+
+func newFakeSlack(t *testing.T, usersJSON string) *fakeSlack {
+	f := &fakeSlack{handlers: make(map[string]http.HandlerFunc)}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := strings.TrimPrefix(r.URL.Path, "/")
+		w.Header().Set("Content-Type", "application/json")
+
+		f.mu.Lock()
+		handler, ok := f.handlers[method]
+		f.mu.Unlock()
+		if ok {
+			handler(w, r)
+			return
+		}
+
+		switch method {
+		case "users.list":
+			_, _ = w.Write([]byte(`{"ok": true, "members": ` + usersJSON + `, "response_metadata": {"next_cursor": ""}}`))
+		case "chat.postMessage":
+			blocks := make([]json.RawMessage, 0)
+			if b := r.FormValue("blocks"); b != "" {
+				if err := json.Unmarshal([]byte(b), &blocks); err != nil {
+					t.Errorf("unexpected blocks: %v", err)
+				}
+			}
+			if len(blocks) > maxBlocksPerMessage {
+				_, _ = w.Write([]byte(`{"ok": false, "error": "invalid_blocks"}`))
+				return
+			}
+
+			f.mu.Lock()
+			f.posts = append(f.posts, fakePost{channel: r.FormValue("channel"), blocks: r.FormValue("blocks")})
+			f.mu.Unlock()
+			_, _ = w.Write([]byte(`{"ok": true, "channel": "` + r.FormValue("channel") + `", "ts": "1234.5678"}`))
+		default:
+			t.Errorf("unexpected Slack API call: %s", method)
+			_, _ = w.Write([]byte(`{"ok": false, "error": "unknown_method"}`))
+		}
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeSlack) channels() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	channels := make([]string, len(f.posts))
+	for i, post := range f.posts {
+		channels[i] = post.channel
+	}
+	return channels
+}
+
+func newTestNotifier(t *testing.T, f *fakeSlack, opts ...NotifierOption) *Notifier {
+	log, _ := logrustest.NewNullLogger()
+	n := NewNotifier("token", "https://console.example.com", log, append(opts, WithSlackAPIURL(f.URL+"/"))...)
+	n.limiter.intervals = map[tier]time.Duration{}
+	return n
+}
+
+func TestNotifyTeams_UnresolvedOwners(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeSlack(t, `[
+		{"id": "U1", "name": "owner1", "profile": {"email": "owner1@example.com"}}
+	]`)
+
+	teams := []naisapi.Team{
+		{
+			Slug:         "team1",
+			SlackChannel: "#team1",
+			Members: []naisapi.Member{
+				{Name: "Owner 1", Email: "owner1@example.com", Role: "OWNER"},
+				{Name: "Former Owner", Email: "former.owner@example.com", Role: "OWNER"},
+			},
+		},
+		{
+			Slug:         "team2",
+			SlackChannel: "#team2",
+			Members: []naisapi.Member{
+				{Name: "Former Owner", Email: "former.owner@example.com", Role: "OWNER"},
+			},
+		},
+	}
+
+	if _, err := newTestNotifier(t, f).NotifyTeams(ctx, naisapi.Values(teams)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if channels := f.channels(); !slices.Equal(channels, []string{"U1", "#team2"}) {
+		t.Fatalf("expected resolved owner and channel fallback to be notified, got: %v", channels)
+	}
+
+	for _, post := range f.posts {
+		if !strings.Contains(post.blocks, "Former Owner") || !strings.Contains(post.blocks, "ikke funnet i Slack") {
+			t.Errorf("expected unresolved owner to be mentioned in message to %q", post.channel)
+		}
+	}
+}
+
+func TestNotifyTeams_Result(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeSlack(t, `[{"id": "U1", "name": "owner1", "profile": {"email": "owner1@example.com"}}]`)
+	f.handlers["chat.postMessage"] = func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("channel") == "#archived" {
+			_, _ = w.Write([]byte(`{"ok": false, "error": "is_archived"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}
+
+	owner := naisapi.Member{Name: "Owner 1", Email: "owner1@example.com", Role: "OWNER"}
+	teams := []naisapi.Team{
+		{Slug: "notified", Members: []naisapi.Member{owner}},
+		{Slug: "archived", SlackChannel: "#archived", Members: []naisapi.Member{{Name: "Member", Email: "member@example.com", Role: "MEMBER"}}},
+		{Slug: "no-recipients", Members: []naisapi.Member{{Name: "Member", Email: "member@example.com", Role: "MEMBER"}}},
+		{Slug: "empty"},
+		{Slug: "deleted", DeletionInProgress: true, Members: []naisapi.Member{owner}},
+	}
+
+	result, err := newTestNotifier(t, f).NotifyTeams(ctx, naisapi.Values(teams))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !slices.Equal(result.Notified, []string{"notified"}) {
+		t.Errorf("unexpected notified teams: %v", result.Notified)
+	}
+
+	if !slices.Equal(result.Failed, []string{"archived", "no-recipients"}) {
+		t.Errorf("unexpected failed teams: %v", result.Failed)
+	}
+
+	if result.Skipped() != 2 {
+		t.Errorf("expected 2 skipped teams, got: %d", result.Skipped())
+	}
+
+	if len(result.Errors) != 2 || result.Errors[0].Recipient != "#archived" || !strings.Contains(result.Errors[0].Error(), "is_archived") {
+		t.Errorf("unexpected errors: %v", result.Errors)
+	}
+
+	if ratio := result.FailureRatio(); ratio < 0.66 || ratio > 0.67 {
+		t.Errorf("expected a failure ratio of 2/3, got: %v", ratio)
+	}
+}
+
+func TestTeamNotifier(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeSlack(t, `[{"id": "U1", "name": "owner1", "profile": {"email": "owner1@example.com"}}]`)
+	store := ledger.NewFileStore(filepath.Join(t.TempDir(), "ledger.json"))
+
+	teams := []naisapi.Team{
+		{Slug: "team1", Members: []naisapi.Member{{Name: "Owner 1", Email: "owner1@example.com", Role: "OWNER"}}},
+		{Slug: "team2", SlackChannel: "#team2", Members: []naisapi.Member{{Name: "Former Owner", Email: "former.owner@example.com", Role: "OWNER"}}},
+	}
+
+	result := &Result{}
+	notifier := newTestNotifier(t, f, WithLedger(store), WithPeriod("2026-10")).TeamNotifier(result)
+	for _, team := range teams {
+		if err := notifier.Notify(ctx, team, review.New(team, "https://console.example.com")); err != nil {
+			t.Fatalf("unexpected error for %s: %v", team.Slug, err)
+		}
+	}
+
+	if channels := f.channels(); !slices.Equal(channels, []string{"U1", "#team2"}) {
+		t.Errorf("expected the owner of team1 and the channel of team2 to be notified, got: %v", channels)
+	}
+
+	if !slices.Equal(result.ChannelFallback, []string{"team2"}) || !slices.Equal(result.UnresolvedOwners, []string{"former.owner@example.com"}) {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	if len(result.Notified) > 0 {
+		t.Errorf("expected the notified teams to be left to the router, got: %v", result.Notified)
+	}
+
+	if err := notifier.Notify(ctx, teams[0], review.New(teams[0], "https://console.example.com")); !errors.Is(err, ledger.ErrAlreadyDelivered) {
+		t.Errorf("expected team1 to be already notified, got: %v", err)
+	}
+}
+
+func TestNotifyTeams_Summary(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeSlack(t, `[{"id": "U1", "name": "owner1", "profile": {"email": "owner1@example.com"}}]`)
+
+	teams := []naisapi.Team{
+		{Slug: "team1", Members: []naisapi.Member{{Name: "Owner 1", Email: "owner1@example.com", Role: "OWNER"}}},
+		{Slug: "team2", SlackChannel: "#team2", Members: []naisapi.Member{{Name: "Former Owner", Email: "former.owner@example.com", Role: "OWNER"}}},
+		{Slug: "team3", SlackChannel: "#team3", Members: []naisapi.Member{{Name: "Member", Email: "member@example.com", Role: "MEMBER"}}},
+		{Slug: "team4"},
+	}
+
+	n := newTestNotifier(t, f, WithAdminChannel("#admins"))
+	result, err := n.NotifyTeams(ctx, naisapi.Values(teams))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result.Merge(&Result{Notified: []string{"team5"}, Failed: []string{"team6"}, Routed: []string{"team5"}})
+	n.PostSummary(ctx, result)
+
+	if channels := f.channels(); !slices.Equal(channels, []string{"U1", "#team2", "#team3", "#admins"}) {
+		t.Fatalf("expected the summary to be posted to the admin channel last, got: %v", channels)
+	}
+
+	summary := f.posts[3].blocks
+	for _, expected := range []string{
+		"*4* team ble varslet, *1* feilet og *1* ble hoppet over.",
+		"*Team som ikke kunne varsles (1):* `team6`",
+		"*Team uten medlemmer (1):* `team4`",
+		"*Team uten eiere (1):* `team3`",
+		"*Team varslet i teamets Slack-kanal (2):* `team2`, `team3`",
+		"*Team varslet på e-post eller webhook (1):* `team5`",
+		"*Eiere som ikke ble funnet i Slack (1):* `former.owner@example.com`",
+	} {
+		if !strings.Contains(summary, expected) {
+			t.Errorf("expected summary to contain %q, got: %s", expected, summary)
+		}
+	}
+}
+
+func TestSummaryList(t *testing.T) {
+	items := make([]string, 500)
+	for i := range items {
+		items[i] = "team-with-a-long-name"
+	}
+
+	text := summaryList(items)
+	if len(text) > maxSummaryListLength+20 || !strings.HasSuffix(text, " til") {
+		t.Errorf("expected a shortened list, got %d characters: %s", len(text), text)
+	}
+}
+
+// newTestRateLimiter returns a rate limiter that does not space out calls, but still retries rate limited calls.
+func newTestRateLimiter(log logrus.FieldLogger) *rateLimiter {
+	l := newRateLimiter(log)
+	l.intervals = map[tier]time.Duration{}
+	return l
+}
+
+func TestRateLimiter_UnknownMethod(t *testing.T) {
+	log, _ := logrustest.NewNullLogger()
+	called := false
+	err := newTestRateLimiter(log).call(context.Background(), "conversations.list", "", func() error {
+		called = true
+		return nil
+	})
+
+	if err == nil || called {
+		t.Errorf("expected the call to an unknown method to fail without being made, got: %v", err)
+	}
+}
+
+func TestNotifyTeams_RateLimited(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeSlack(t, `[{"id": "U1", "name": "owner1", "profile": {"email": "owner1@example.com"}}]`)
+
+	rateLimited := true
+	f.handlers["chat.postMessage"] = func(w http.ResponseWriter, r *http.Request) {
+		if rateLimited {
+			rateLimited = false
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		f.posts = append(f.posts, fakePost{channel: r.FormValue("channel")})
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}
+
+	teams := []naisapi.Team{
+		{Slug: "team1", Members: []naisapi.Member{{Name: "Owner 1", Email: "owner1@example.com", Role: "OWNER"}}},
+	}
+
+	start := time.Now()
+	if _, err := newTestNotifier(t, f).NotifyTeams(ctx, naisapi.Values(teams)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if channels := f.channels(); !slices.Equal(channels, []string{"U1"}) {
+		t.Fatalf("expected rate limited message to be retried, got: %v", channels)
+	}
+
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("expected to wait for Retry-After before retrying, waited %v", elapsed)
+	}
+}
+
+func TestNotifyTeams_DryRun(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeSlack(t, `[{"id": "U1", "name": "owner1", "profile": {"email": "owner1@example.com"}}]`)
+
+	teams := []naisapi.Team{
+		{Slug: "team1", Members: []naisapi.Member{{Name: "Owner 1", Email: "owner1@example.com", Role: "OWNER"}}},
+	}
+
+	output := &bytes.Buffer{}
+	if _, err := newTestNotifier(t, f, WithDryRun(output)).NotifyTeams(ctx, naisapi.Values(teams)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if channels := f.channels(); len(channels) != 0 {
+		t.Fatalf("expected no messages to be posted, got: %v", channels)
+	}
+
+	msg := dryRunMessage{}
+	if err := json.Unmarshal(output.Bytes(), &msg); err != nil {
+		t.Fatalf("unable to decode dry-run output %q: %v", output.String(), err)
+	}
+
+	if msg.Recipient != "U1" || !slices.Equal(msg.Teams, []string{"team1"}) {
+		t.Errorf("unexpected recipient or teams: %+v", msg)
+	}
+
+	blocks := slackapi.Blocks{}
+	if err := json.Unmarshal(msg.Blocks, &blocks); err != nil {
+		t.Fatalf("unable to decode blocks: %v", err)
+	} else if len(blocks.BlockSet) == 0 {
+		t.Errorf("expected blocks in dry-run output")
+	}
+}
+
+func TestNotifyTeams_Redirect(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeSlack(t, `[{"id": "U1", "name": "owner1", "profile": {"email": "owner1@example.com"}}]`)
+
+	teams := []naisapi.Team{
+		{Slug: "team1", Members: []naisapi.Member{{Name: "Owner 1", Email: "owner1@example.com", Role: "OWNER"}}},
+		{Slug: "team2", SlackChannel: "#team2", Members: []naisapi.Member{{Name: "Member", Email: "member@example.com", Role: "MEMBER"}}},
+	}
+
+	if _, err := newTestNotifier(t, f, WithRedirect("#test-channel")).NotifyTeams(ctx, naisapi.Values(teams)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if channels := f.channels(); !slices.Equal(channels, []string{"#test-channel", "#test-channel"}) {
+		t.Fatalf("expected all messages to be redirected, got: %v", channels)
+	}
+
+	for i, expected := range []string{"`U1`", "`#team2`"} {
+		if !strings.Contains(f.posts[i].blocks, "Omdirigert melding. Ville blitt sendt til "+expected) {
+			t.Errorf("expected redirected message to mention %s, got: %s", expected, f.posts[i].blocks)
+		}
+	}
+}
+
+func TestNotifyTeams_RedirectDigest(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeSlack(t, `[{"id": "U1", "name": "owner1", "profile": {"email": "owner1@example.com"}}]`)
+
+	// The intro and the sections of the first ten teams fill the first digest message with exactly 50 blocks
+	teams := make([]naisapi.Team, 0)
+	for i := range 20 {
+		team := naisapi.Team{
+			Slug:    fmt.Sprintf("team%02d", i),
+			Members: []naisapi.Member{{Name: "Owner 1", Email: "owner1@example.com", Role: "OWNER"}},
+		}
+		if i < 7 {
+			team.Purpose = "Formål"
+		}
+		teams = append(teams, team)
+	}
+
+	result, err := newTestNotifier(t, f, WithDigest(), WithRedirect("#test-channel")).NotifyTeams(ctx, naisapi.Values(teams))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Failed) != 0 || len(result.Notified) != len(teams) {
+		t.Errorf("expected every team to be notified, got: %+v", result)
+	}
+
+	if channels := f.channels(); len(channels) < 2 {
+		t.Errorf("expected the digest to be split into several messages, got: %v", channels)
+	}
+}
+
+func TestNotifyTeams_Ledger(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeSlack(t, `[{"id": "U1", "name": "owner1", "profile": {"email": "owner1@example.com"}}]`)
+	store := ledger.NewFileStore(filepath.Join(t.TempDir(), "ledger.json"))
+
+	teams := []naisapi.Team{
+		{Slug: "team1", Members: []naisapi.Member{{Name: "Owner 1", Email: "owner1@example.com", Role: "OWNER"}}},
+	}
+
+	if _, err := newTestNotifier(t, f, WithLedger(store)).NotifyTeams(ctx, naisapi.Values(teams)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deliveries, err := store.List(ctx, "team1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(deliveries) != 1 {
+		t.Fatalf("expected 1 delivery, got: %v", deliveries)
+	}
+
+	if d := deliveries[0]; d.Kind != ledger.KindReminder || d.Recipient != "U1" || d.Channel != "U1" || d.Timestamp != "1234.5678" {
+		t.Errorf("unexpected delivery: %+v", d)
+	}
+}
+
+func TestNotifyTeams_SkipsDelivered(t *testing.T) {
+	ctx := context.Background()
+	teams := []naisapi.Team{
+		{Slug: "team1", Members: []naisapi.Member{{Name: "Owner 1", Email: "owner1@example.com", Role: "OWNER"}}},
+		{Slug: "team2", Members: []naisapi.Member{{Name: "Owner 1", Email: "owner1@example.com", Role: "OWNER"}}},
+	}
+
+	for name, opts := range map[string][]NotifierOption{
+		"per team": nil,
+		"digest":   {WithDigest()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			f := newFakeSlack(t, `[{"id": "U1", "name": "owner1", "profile": {"email": "owner1@example.com"}}]`)
+			store := ledger.NewFileStore(filepath.Join(t.TempDir(), "ledger.json"))
+
+			// team1 was notified before the previous run failed
+			err := store.Record(ctx, ledger.Delivery{Period: "2026-10", TeamSlug: "team1", Kind: ledger.KindReminder, Recipient: "U1"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			n := newTestNotifier(t, f, append(opts, WithLedger(store), WithPeriod("2026-10"))...)
+			for i, expected := range [][]string{{"team1"}, {"team1", "team2"}} {
+				result, err := n.NotifyTeams(ctx, naisapi.Values(teams))
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if !slices.Equal(result.AlreadyNotified, expected) || len(result.Notified)+len(result.AlreadyNotified) != 2 {
+					t.Errorf("run %d: expected %v to be skipped as already notified, got: %+v", i+1, expected, result)
+				}
+			}
+
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if len(f.posts) != 1 {
+				t.Fatalf("expected a single message, got: %d", len(f.posts))
+			}
+
+			if blocks := f.posts[0].blocks; strings.Contains(blocks, "team1") || !strings.Contains(blocks, "team2") {
+				t.Errorf("expected the message to only be about team2, got: %s", blocks)
+			}
+		})
+	}
+}
+
+func TestNotifyTeams_PartlyDelivered(t *testing.T) {
+	ctx := context.Background()
+	teams := []naisapi.Team{
+		{Slug: "team1", Members: []naisapi.Member{
+			{Name: "Owner 1", Email: "owner1@example.com", Role: "OWNER"},
+			{Name: "Owner 2", Email: "owner2@example.com", Role: "OWNER"},
+		}},
+	}
+
+	for name, opts := range map[string][]NotifierOption{
+		"per team": nil,
+		"digest":   {WithDigest()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			f := newFakeSlack(t, `[
+				{"id": "U1", "name": "owner1", "profile": {"email": "owner1@example.com"}},
+				{"id": "U2", "name": "owner2", "profile": {"email": "owner2@example.com"}}
+			]`)
+			f.handlers["chat.postMessage"] = func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(`{"ok": false, "error": "channel_not_found"}`))
+			}
+			store := ledger.NewFileStore(filepath.Join(t.TempDir(), "ledger.json"))
+
+			// Owner 1 was notified before the previous run failed, and owner 2 still cannot be notified
+			err := store.Record(ctx, ledger.Delivery{Period: "2026-10", TeamSlug: "team1", Kind: ledger.KindReminder, Recipient: "U1"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			n := newTestNotifier(t, f, append(opts, WithLedger(store), WithPeriod("2026-10"))...)
+			result, err := n.NotifyTeams(ctx, naisapi.Values(teams))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(result.Failed, []string{"team1"}) || len(result.AlreadyNotified) > 0 {
+				t.Errorf("expected team1 to fail, got: %+v", result)
+			}
+		})
+	}
+}
+
+func TestNotifyTeams_IgnoresLedgerWithoutRealRecipients(t *testing.T) {
+	ctx := context.Background()
+	teams := []naisapi.Team{
+		{Slug: "team1", Members: []naisapi.Member{{Name: "Owner 1", Email: "owner1@example.com", Role: "OWNER"}}},
+	}
+
+	for name, opts := range map[string][]NotifierOption{
+		"dry run":         {WithDryRun(io.Discard)},
+		"redirect":        {WithRedirect("#test-channel")},
+		"digest redirect": {WithDigest(), WithRedirect("#test-channel")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			f := newFakeSlack(t, `[{"id": "U1", "name": "owner1", "profile": {"email": "owner1@example.com"}}]`)
+			n := newTestNotifier(t, f, append(opts, WithLedger(failingLedger{}))...)
+			result, err := n.NotifyTeams(ctx, naisapi.Values(teams))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(result.Notified, []string{"team1"}) || len(result.Errors) > 0 {
+				t.Errorf("expected team1 to be notified without looking in the ledger, got: %+v", result)
+			}
+		})
+	}
+}
+
+// failingLedger is a ledger where every lookup fails.
+type failingLedger struct {
+	ledger.Store
+}
+
+func (failingLedger) Delivered(context.Context, ledger.Key) (bool, error) {
+	return false, errors.New("ledger unavailable")
+}
+
+func TestNotifyTeams_LedgerError(t *testing.T) {
+	ctx := context.Background()
+	teams := []naisapi.Team{
+		{Slug: "team1", Members: []naisapi.Member{{Name: "Owner 1", Email: "owner1@example.com", Role: "OWNER"}}},
+	}
+
+	for name, opts := range map[string][]NotifierOption{
+		"per team": nil,
+		"digest":   {WithDigest()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			f := newFakeSlack(t, `[{"id": "U1", "name": "owner1", "profile": {"email": "owner1@example.com"}}]`)
+			n := newTestNotifier(t, f, append(opts, WithLedger(failingLedger{}))...)
+			result, err := n.NotifyTeams(ctx, naisapi.Values(teams))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(result.Failed, []string{"team1"}) {
+				t.Errorf("expected team1 to fail, got: %+v", result)
+			}
+
+			if len(result.Errors) != 1 || result.Errors[0].Recipient != "U1" {
+				t.Errorf("expected the ledger error to be reported for U1, got: %v", result.Errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	slackapi "github.com/slack-go/slack"
+)
+
+// poster delivers a rendered message about one or more teams to a recipient, which is either a Slack user ID or a
+// channel.
+type poster interface {
+	post(ctx context.Context, recipient string, msg message) (postedMessage, error)
+}
+
+// postedMessage identifies a message that has been posted to Slack. It is empty when nothing was posted, e.g. in a dry
+// run.
+type postedMessage struct {
+	channel   string
+	timestamp string
+}
+
+// slackPoster posts messages to Slack within the rate limits of the API.
+type slackPoster struct {
+	api     *slackapi.Client
+	limiter *rateLimiter
+}
+
+func (p *slackPoster) post(ctx context.Context, recipient string, msg message) (postedMessage, error) {
+	posted := postedMessage{}
+	err := p.limiter.call(ctx, "chat.postMessage", recipient, func() error {
+		var err error
+		posted.channel, posted.timestamp, err = p.api.PostMessageContext(ctx, recipient, msg.options()...)
+		return err
+	})
+	return posted, err
+}
+
+// dryRunMessage is a message that would have been posted to Slack, written as a single line of JSON by the dry-run
+// poster.
+type dryRunMessage struct {
+	Recipient string          `json:"recipient"`
+	Teams     []string        `json:"teams"`
+	Text      string          `json:"text"`
+	Blocks    json.RawMessage `json:"blocks"`
+}
+
+// dryRunPoster writes the messages to a writer instead of posting them, so changes to the messages can be reviewed
+// without notifying anyone.
+type dryRunPoster struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (p *dryRunPoster) post(_ context.Context, recipient string, msg message) (postedMessage, error) {
+	blocks, err := json.Marshal(msg.blocks)
+	if err != nil {
+		return postedMessage{}, fmt.Errorf("render message: %w", err)
+	}
+
+	data, err := json.Marshal(dryRunMessage{
+		Recipient: recipient,
+		Teams:     msg.teamSlugs,
+		Text:      msg.text,
+		Blocks:    blocks,
+	})
+	if err != nil {
+		return postedMessage{}, fmt.Errorf("encode message: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err = fmt.Fprintf(p.w, "%s\n", data)
+	return postedMessage{}, err
+}
+
+// redirectPoster sends every message to a single recipient instead of the real one, with a note at the top of the
+// message about who would have received it. Used to check the messages end to end in a test workspace or channel.
+type redirectPoster struct {
+	next      poster
+	recipient string
+}
+
+func (p *redirectPoster) post(ctx context.Context, recipient string, msg message) (postedMessage, error) {
+	note := slackapi.NewContextBlock(
+		"",
+		slackapi.NewTextBlockObject(
+			slackapi.MarkdownType,
+			fmt.Sprintf(
+				":test_tube: Omdirigert melding. Ville blitt sendt til `%s` om teamene: %s",
+				recipient,
+				strings.Join(msg.teamSlugs, ", "),
+			),
+			false,
+			false,
+		),
+	)
+
+	return p.next.post(ctx, p.recipient, message{
+		text:      msg.text,
+		blocks:    append([]slackapi.Block{note}, msg.blocks...),
+		teamSlugs: msg.teamSlugs,
+	})
+}
@@ -0,0 +1,155 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	slackapi "github.com/slack-go/slack"
+)
+
+// maxRateLimitedRetries is the number of times a call is retried after Slack has responded with 429 Too Many Requests.
+const maxRateLimitedRetries = 5
+
+// tier is a Slack Web API rate limit tier, see https://api.slack.com/apis/rate-limits.
+type tier int
+
+const (
+	tier2 tier = iota
+	tier3
+	tier4
+
+	// tierPostMessage is the special limit for chat.postMessage, which allows roughly one message per second to each
+	// channel.
+	tierPostMessage
+)
+
+// defaultIntervals is the minimum time between two calls in the tier. The documented limits are per minute and allow short
+// bursts, but spreading the calls evenly avoids hitting the limit in the first place.
+var defaultIntervals = map[tier]time.Duration{
+	tier2:           time.Minute / 20,
+	tier3:           time.Minute / 50,
+	tier4:           time.Minute / 100,
+	tierPostMessage: time.Second,
+}
+
+// methodTiers maps the Slack API methods used by the notifier to their rate limit tier. Calls to methods that are not
+// in the map fail, so a new method is not paced with the wrong limit.
+var methodTiers = map[string]tier{
+	"users.list":       tier2,
+	"reactions.get":    tier3,
+	"chat.postMessage": tierPostMessage,
+}
+
+// rateLimiter spaces out calls to the Slack API according to the rate limit tier of each method, and retries calls
+// that are rate limited by Slack after the delay Slack asks for. It also works as a circuit breaker: after a fatal
+// error, e.g. a revoked token, every later call fails right away with the same error.
+type rateLimiter struct {
+	mu        sync.Mutex
+	intervals map[tier]time.Duration
+	next      map[string]time.Time
+	fatal     error
+	log       logrus.FieldLogger
+}
+
+func newRateLimiter(log logrus.FieldLogger) *rateLimiter {
+	return &rateLimiter{
+		intervals: defaultIntervals,
+		next:      make(map[string]time.Time),
+		log:       log,
+	}
+}
+
+// call runs fn for the Slack API method, waiting for the rate limit first. The key identifies what the limit applies
+// to, e.g. the channel for chat.postMessage, and can be empty for methods that are limited per workspace.
+func (l *rateLimiter) call(ctx context.Context, method, key string, fn func() error) error {
+	for retry := 0; ; retry++ {
+		if err := l.fatalError(); err != nil {
+			return err
+		}
+
+		if err := l.wait(ctx, method, key); err != nil {
+			return err
+		}
+
+		err := fn()
+		if isFatal(err) {
+			return l.trip(method, err)
+		}
+
+		var rateLimitedErr *slackapi.RateLimitedError
+		if !errors.As(err, &rateLimitedErr) || retry >= maxRateLimitedRetries {
+			return err
+		}
+
+		l.log.WithFields(logrus.Fields{
+			"method":      method,
+			"retry":       retry + 1,
+			"retry_after": rateLimitedErr.RetryAfter.String(),
+		}).Warnf("rate limited by Slack, retrying")
+		l.delay(method, key, rateLimitedErr.RetryAfter)
+	}
+}
+
+// fatalError returns the fatal error that stopped all calls, if any.
+func (l *rateLimiter) fatalError() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.fatal
+}
+
+// trip stops all later calls after a fatal error from the method, and returns the error they fail with.
+func (l *rateLimiter) trip(method string, err error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.fatal == nil {
+		l.log.WithError(err).WithField("method", method).Errorf("fatal error from Slack, stopping all calls")
+		l.fatal = fmt.Errorf("%w: %s: %w", ErrFatal, method, err)
+	}
+	return l.fatal
+}
+
+// wait blocks until the next call to the method is allowed, and reserves the following slot.
+func (l *rateLimiter) wait(ctx context.Context, method, key string) error {
+	methodTier, ok := methodTiers[method]
+	if !ok {
+		return fmt.Errorf("no rate limit tier for Slack API method %q", method)
+	}
+
+	interval := l.intervals[methodTier]
+	limitKey := method + "/" + key
+
+	l.mu.Lock()
+	now := time.Now()
+	at := now
+	if next := l.next[limitKey]; next.After(now) {
+		at = next
+	}
+	l.next[limitKey] = at.Add(interval)
+	l.mu.Unlock()
+
+	if at.Equal(now) {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Until(at)):
+		return nil
+	}
+}
+
+// delay postpones the next call to the method until at least d from now.
+func (l *rateLimiter) delay(method, key string, d time.Duration) {
+	limitKey := method + "/" + key
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if at := time.Now().Add(d); at.After(l.next[limitKey]) {
+		l.next[limitKey] = at
+	}
+}
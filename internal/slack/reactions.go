@@ -0,0 +1,161 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nais/slack-teams-notification/internal/acks"
+	"github.com/nais/slack-teams-notification/internal/ledger"
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/sirupsen/logrus"
+	slackapi "github.com/slack-go/slack"
+)
+
+// CheckReactions looks for the emoji among the reactions to the messages delivered since the latest reminder about each
+// team, and records a reaction from one of the owners as a confirmation that the members of the team are correct. This
+// is an alternative to the acknowledgement buttons that does not need a public endpoint for interactions.
+func (n *Notifier) CheckReactions(ctx context.Context, teams iter.Seq2[naisapi.Team, error], emoji string) error {
+	if n.ledger == nil || n.acks == nil {
+		return fmt.Errorf("checking reactions requires a ledger and an acknowledgement store")
+	}
+
+	emoji = strings.Trim(emoji, ":")
+	acknowledged := make([]string, 0)
+	failed := make([]string, 0)
+	defer func() {
+		n.log.WithFields(logrus.Fields{
+			"teams_acknowledged": acknowledged,
+			"teams_failed":       failed,
+			"emoji":              emoji,
+		}).Infof("reaction check summary")
+	}()
+
+	for team, err := range teams {
+		if err != nil {
+			return err
+		}
+
+		if team.IsBeingDeleted() || len(team.Members) == 0 {
+			continue
+		}
+
+		ok, err := n.checkTeamReactions(ctx, team, emoji)
+		if err != nil {
+			n.log.
+				WithError(err).
+				WithField("team_slug", team.Slug).
+				Errorf("check reactions")
+			failed = append(failed, team.Slug)
+
+			if err := n.limiter.fatalError(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if ok {
+			acknowledged = append(acknowledged, team.Slug)
+		}
+	}
+
+	return nil
+}
+
+// checkTeamReactions records an acknowledgement if an owner has reacted with the emoji to one of the messages about the
+// team since the latest reminder, and reports whether it did.
+func (n *Notifier) checkTeamReactions(ctx context.Context, team naisapi.Team, emoji string) (bool, error) {
+	deliveries, err := n.ledger.List(ctx, team.Slug)
+	if err != nil {
+		return false, err
+	}
+
+	reminder, ok := lastSent(deliveries, ledger.KindReminder, time.Time{})
+	if !ok {
+		return false, nil
+	}
+	remindedAt := reminder.SentAt
+
+	owners, _, err := n.resolveOwners(ctx, team)
+	if err != nil {
+		return false, err
+	}
+
+	answered, err := n.isAcknowledged(ctx, team.Slug, remindedAt, owners)
+	if err != nil || answered {
+		return false, err
+	}
+
+	for _, item := range reactableMessages(deliveries, remindedAt) {
+		var reactions []slackapi.ItemReaction
+		err := n.limiter.call(ctx, "reactions.get", "", func() error {
+			var err error
+			reactions, err = n.slackApi.GetReactionsContext(ctx, item, slackapi.GetReactionsParameters{Full: true})
+			return err
+		})
+		if err != nil {
+			return false, fmt.Errorf("get reactions to %s/%s: %w", item.Channel, item.Timestamp, err)
+		}
+
+		userID, ok := reactingOwner(reactions, emoji, owners)
+		if !ok {
+			continue
+		}
+
+		err = n.acks.Record(ctx, acks.Acknowledgement{
+			TeamSlug: team.Slug,
+			UserID:   userID,
+			Status:   acks.StatusConfirmed,
+			At:       time.Now(),
+		})
+		if err != nil {
+			return false, err
+		}
+
+		n.log.
+			WithField("team_slug", team.Slug).
+			WithField("user_id", userID).
+			Infof("acknowledgement recorded from reaction")
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// reactableMessages returns the messages to the owners and the team that have been delivered since the reminder, once
+// each, since a digest delivers the same message about several teams.
+func reactableMessages(deliveries []ledger.Delivery, since time.Time) []slackapi.ItemRef {
+	items := make([]slackapi.ItemRef, 0)
+	for _, d := range deliveries {
+		if d.Kind == ledger.KindAdminChannel || d.Channel == "" || d.Timestamp == "" || d.SentAt.Before(since) {
+			continue
+		}
+
+		item := slackapi.NewRefToMessage(d.Channel, d.Timestamp)
+		if !slices.Contains(items, item) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// reactingOwner returns the first of the owners that has reacted with the emoji.
+func reactingOwner(reactions []slackapi.ItemReaction, emoji string, owners []string) (string, bool) {
+	for _, reaction := range reactions {
+		// Reactions with a skin tone are named e.g. "+1::skin-tone-2"
+		name, _, _ := strings.Cut(reaction.Name, "::")
+		if name != emoji {
+			continue
+		}
+
+		for _, userID := range reaction.Users {
+			if slices.Contains(owners, userID) {
+				return userID, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,71 @@
+package slack
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nais/slack-teams-notification/internal/acks"
+	"github.com/nais/slack-teams-notification/internal/ledger"
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+)
+
+func TestCheckReactions(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeSlack(t, `[
+		{"id": "U1", "name": "owner1", "profile": {"email": "owner1@example.com"}},
+		{"id": "U2", "name": "member", "profile": {"email": "member@example.com"}}
+	]`)
+	f.handlers["reactions.get"] = func(w http.ResponseWriter, r *http.Request) {
+		reactions := map[string]string{
+			// An owner has confirmed
+			"1.1": `[{"name": "white_check_mark::skin-tone-2", "count": 1, "users": ["U1"]}]`,
+			// Only a member has confirmed
+			"2.2": `[{"name": "white_check_mark", "count": 1, "users": ["U2"]}, {"name": "eyes", "count": 1, "users": ["U1"]}]`,
+		}[r.FormValue("timestamp")]
+		_, _ = w.Write([]byte(`{"ok": true, "type": "message", "message": {"reactions": ` + reactions + `}}`))
+	}
+
+	dir := t.TempDir()
+	deliveries := ledger.NewFileStore(filepath.Join(dir, "ledger.json"))
+	answers := acks.NewFileStore(filepath.Join(dir, "acks.json"))
+
+	members := []naisapi.Member{
+		{Name: "Owner 1", Email: "owner1@example.com", Role: "OWNER"},
+		{Name: "Member", Email: "member@example.com", Role: "MEMBER"},
+	}
+	teams := []naisapi.Team{
+		{Slug: "team1", Members: members},
+		{Slug: "team2", Members: members},
+	}
+
+	sentAt := time.Now().Add(-time.Hour)
+	err := deliveries.Record(
+		ctx,
+		ledger.Delivery{TeamSlug: "team1", Kind: ledger.KindReminder, Recipient: "U1", Channel: "D1", Timestamp: "1.1", SentAt: sentAt},
+		ledger.Delivery{TeamSlug: "team2", Kind: ledger.KindReminder, Recipient: "U1", Channel: "D1", Timestamp: "2.2", SentAt: sentAt},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	n := newTestNotifier(t, f, WithLedger(deliveries), WithAcknowledgements(answers))
+	if err := n.CheckReactions(ctx, naisapi.Values(teams), ":white_check_mark:"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	team1, err := answers.List(ctx, "team1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(team1) != 1 || team1[0].UserID != "U1" || team1[0].Status != acks.StatusConfirmed {
+		t.Errorf("expected team1 to be acknowledged by U1, got: %v", team1)
+	}
+
+	if team2, _ := answers.List(ctx, "team2"); len(team2) != 0 {
+		t.Errorf("expected team2 not to be acknowledged, got: %v", team2)
+	}
+}
@@ -0,0 +1,125 @@
+package slack
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Result is what happened to the teams during a notification run.
+type Result struct {
+	// Notified are the teams where at least one recipient was notified.
+	Notified []string
+
+	// Failed are the teams where no recipient could be notified.
+	Failed []string
+
+	// NoMembers are the teams that were skipped because they have no members.
+	NoMembers []string
+
+	// BeingDeleted are the teams that were skipped because they are being deleted.
+	BeingDeleted []string
+
+	// AlreadyNotified are the teams that were skipped because every recipient has already been notified about them in
+	// this period.
+	AlreadyNotified []string
+
+	// NoOwners are the teams that have no owners.
+	NoOwners []string
+
+	// ChannelFallback are the teams that were notified in their Slack channel, since none of the owners could be found
+	// in Slack.
+	ChannelFallback []string
+
+	// Routed are the teams that were notified through the channel they prefer instead of Slack.
+	Routed []string
+
+	// UnresolvedOwners are the email addresses of the owners that could not be found in Slack.
+	UnresolvedOwners []string
+
+	// Errors are the errors for each recipient that could not be notified.
+	Errors []RecipientError
+}
+
+// RecipientError is an error that prevented a recipient from being notified about the teams.
+type RecipientError struct {
+	// Recipient is the Slack user ID or channel, the email address of an owner that could not be looked up, or empty
+	// if the team had no recipient.
+	Recipient string
+	TeamSlugs []string
+	Err       error
+}
+
+func (e RecipientError) Error() string {
+	return fmt.Sprintf("notify %q about %v: %v", e.Recipient, e.TeamSlugs, e.Err)
+}
+
+func (e RecipientError) Unwrap() error {
+	return e.Err
+}
+
+// Skipped returns the number of teams that were not notified on purpose.
+func (r *Result) Skipped() int {
+	return len(r.NoMembers) + len(r.BeingDeleted) + len(r.AlreadyNotified)
+}
+
+// FailureRatio returns the share of the teams that should have been notified, but were not. It is zero if there were no
+// teams to notify.
+func (r *Result) FailureRatio() float64 {
+	total := len(r.Notified) + len(r.Failed)
+	if total == 0 {
+		return 0
+	}
+	return float64(len(r.Failed)) / float64(total)
+}
+
+// Merge adds the teams of the other result, e.g. the teams that were notified one at a time by a TeamNotifier, or
+// through the channel they prefer instead of Slack, so the summary and the failure ratio cover every team.
+func (r *Result) Merge(other *Result) {
+	r.Notified = append(r.Notified, other.Notified...)
+	r.Failed = append(r.Failed, other.Failed...)
+	r.NoMembers = append(r.NoMembers, other.NoMembers...)
+	r.BeingDeleted = append(r.BeingDeleted, other.BeingDeleted...)
+	r.AlreadyNotified = append(r.AlreadyNotified, other.AlreadyNotified...)
+	r.NoOwners = append(r.NoOwners, other.NoOwners...)
+	r.ChannelFallback = append(r.ChannelFallback, other.ChannelFallback...)
+	r.Routed = append(r.Routed, other.Routed...)
+	for _, email := range other.UnresolvedOwners {
+		r.addUnresolvedOwner(email)
+	}
+	r.Errors = append(r.Errors, other.Errors...)
+}
+
+func (r *Result) addUnresolvedOwner(email string) {
+	if !slices.Contains(r.UnresolvedOwners, email) {
+		r.UnresolvedOwners = append(r.UnresolvedOwners, email)
+	}
+}
+
+func (r *Result) addError(recipient string, teamSlugs []string, err error) {
+	r.Errors = append(r.Errors, RecipientError{Recipient: recipient, TeamSlugs: teamSlugs, Err: err})
+}
+
+// Log logs a summary of the run. It should be called once every team has been added, see Merge.
+func (r *Result) Log(log logrus.FieldLogger) {
+	log.WithFields(logrus.Fields{
+		"teams_failed":           r.Failed,
+		"teams_no_members":       r.NoMembers,
+		"teams_being_deleted":    r.BeingDeleted,
+		"teams_already_notified": r.AlreadyNotified,
+		"teams_no_owners":        r.NoOwners,
+		"teams_fallback":         r.ChannelFallback,
+		"teams_routed":           r.Routed,
+		"unresolved_owners":      r.UnresolvedOwners,
+		"count_notified":         len(r.Notified),
+		"count_failed":           len(r.Failed),
+		"count_skipped":          r.Skipped(),
+		"count_no_members":       len(r.NoMembers),
+		"count_being_deleted":    len(r.BeingDeleted),
+		"count_already_notified": len(r.AlreadyNotified),
+		"count_unresolved":       len(r.UnresolvedOwners),
+		"count_errors":           len(r.Errors),
+		"failure_ratio":          r.FailureRatio(),
+	}).Infof("notification run summary")
+}
@@ -0,0 +1,136 @@
+package teamfilter
+
+import (
+	"fmt"
+	"iter"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/sirupsen/logrus"
+)
+
+// Filter decides which teams to include based on their slug. A pattern is either a regular expression enclosed in
+// slashes, e.g. "/^team-[ab]$/", or a glob pattern as supported by path.Match, e.g. "nais-*". A glob pattern without
+// wildcards matches a single slug exactly.
+type Filter struct {
+	include patterns
+	exclude patterns
+}
+
+type patterns struct {
+	// exact contains the patterns without any wildcards, so the common case of listing slugs is a single lookup
+	exact   map[string]struct{}
+	globs   []string
+	regexps []*regexp.Regexp
+}
+
+// New creates a filter that includes the teams matching any of the include patterns, or all teams if there are none,
+// except the teams matching any of the exclude patterns.
+func New(include, exclude []string) (*Filter, error) {
+	includePatterns, err := parsePatterns(include)
+	if err != nil {
+		return nil, fmt.Errorf("include: %w", err)
+	}
+
+	excludePatterns, err := parsePatterns(exclude)
+	if err != nil {
+		return nil, fmt.Errorf("exclude: %w", err)
+	}
+
+	return &Filter{
+		include: includePatterns,
+		exclude: excludePatterns,
+	}, nil
+}
+
+// Match reports whether the team with the given slug passes the filter. A nil filter matches all teams.
+func (f *Filter) Match(slug string) bool {
+	if f == nil {
+		return true
+	}
+
+	if !f.include.empty() && !f.include.match(slug) {
+		return false
+	}
+
+	return !f.exclude.match(slug)
+}
+
+// Apply returns an iterator that only yields the teams that pass the filter. Errors are always passed through.
+func (f *Filter) Apply(teams iter.Seq2[naisapi.Team, error], log logrus.FieldLogger) iter.Seq2[naisapi.Team, error] {
+	if f == nil || (f.include.empty() && f.exclude.empty()) {
+		return teams
+	}
+
+	return func(yield func(naisapi.Team, error) bool) {
+		for team, err := range teams {
+			if err == nil {
+				if !f.Match(team.Slug) {
+					log.WithField("team_slug", team.Slug).Debugf("team dropped by filter")
+					continue
+				}
+				log.WithField("team_slug", team.Slug).Debugf("team matched by filter")
+			}
+
+			if !yield(team, err) {
+				return
+			}
+		}
+	}
+}
+
+func parsePatterns(rawPatterns []string) (patterns, error) {
+	p := patterns{
+		exact: make(map[string]struct{}),
+	}
+
+	for _, raw := range rawPatterns {
+		raw = strings.TrimSpace(raw)
+		switch {
+		case raw == "":
+			continue
+		case len(raw) > 1 && strings.HasPrefix(raw, "/") && strings.HasSuffix(raw, "/"):
+			re, err := regexp.Compile(raw[1 : len(raw)-1])
+			if err != nil {
+				return patterns{}, fmt.Errorf("invalid regular expression %q: %w", raw, err)
+			}
+			p.regexps = append(p.regexps, re)
+		case strings.ContainsAny(raw, `*?[\`):
+			if _, err := path.Match(raw, ""); err != nil {
+				return patterns{}, fmt.Errorf("invalid glob pattern %q: %w", raw, err)
+			}
+			p.globs = append(p.globs, raw)
+		default:
+			p.exact[raw] = struct{}{}
+		}
+	}
+
+	return p, nil
+}
+
+func (p patterns) empty() bool {
+	return len(p.exact) == 0 && len(p.globs) == 0 && len(p.regexps) == 0
+}
+
+func (p patterns) match(slug string) bool {
+	if _, ok := p.exact[slug]; ok {
+		return true
+	}
+
+	for _, glob := range p.globs {
+		// The pattern has been validated when parsed, so no error can occur here
+		if ok, _ := path.Match(glob, slug); ok {
+			return true
+		}
+	}
+
+	for _, re := range p.regexps {
+		if re.MatchString(slug) {
+			return true
+		}
+	}
+
+	return false
+}
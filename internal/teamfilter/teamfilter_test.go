@@ -0,0 +1,96 @@
+package teamfilter_test
+
+import (
+	"testing"
+
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/nais/slack-teams-notification/internal/teamfilter"
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestFilter_Match(t *testing.T) {
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected map[string]bool
+	}{
+		{
+			name:     "no patterns",
+			expected: map[string]bool{"team-a": true, "nais-test": true},
+		},
+		{
+			name:     "exact slugs",
+			include:  []string{"team-a", "team-c"},
+			expected: map[string]bool{"team-a": true, "team-b": false, "team-c": true},
+		},
+		{
+			name:     "include glob",
+			include:  []string{"team-a*"},
+			expected: map[string]bool{"team-a": true, "team-abc": true, "team-b": false},
+		},
+		{
+			name:     "exclude glob",
+			exclude:  []string{"nais-*"},
+			expected: map[string]bool{"team-a": true, "nais-test": false, "nais": true},
+		},
+		{
+			name:     "include regex",
+			include:  []string{"/^team-[ab]$/"},
+			expected: map[string]bool{"team-a": true, "team-b": true, "team-c": false, "team-ab": false},
+		},
+		{
+			name:     "exclude wins over include",
+			include:  []string{"team-*"},
+			exclude:  []string{"team-b", "/-test$/"},
+			expected: map[string]bool{"team-a": true, "team-b": false, "team-a-test": false, "other": false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := teamfilter.New(tt.include, tt.exclude)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for slug, expected := range tt.expected {
+				if filter.Match(slug) != expected {
+					t.Errorf("expected Match(%q) to be %v", slug, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestNew_InvalidPatterns(t *testing.T) {
+	if _, err := teamfilter.New([]string{"/team-[/"}, nil); err == nil {
+		t.Errorf("expected error for invalid regular expression")
+	}
+
+	if _, err := teamfilter.New(nil, []string{"team-["}); err == nil {
+		t.Errorf("expected error for invalid glob pattern")
+	}
+}
+
+func TestFilter_Apply(t *testing.T) {
+	log, hook := logrustest.NewNullLogger()
+	log.SetLevel(logrus.DebugLevel)
+
+	filter, err := teamfilter.New(nil, []string{"nais-*"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	teams, err := naisapi.Collect(filter.Apply(naisapi.Values([]naisapi.Team{{Slug: "team-a"}, {Slug: "nais-test"}}), log))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(teams) != 1 || teams[0].Slug != "team-a" {
+		t.Errorf("unexpected teams: %v", teams)
+	}
+
+	if len(hook.AllEntries()) != 2 {
+		t.Errorf("expected matched and dropped teams to be logged, got: %d entries", len(hook.AllEntries()))
+	}
+}
@@ -0,0 +1,48 @@
+package teamsource
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/nais/slack-teams-notification/internal/teamfilter"
+	"github.com/sirupsen/logrus"
+	"go.yaml.in/yaml/v3"
+)
+
+// fileContent is the format of a static team file. Since JSON is a subset of YAML, the same format is used for both.
+type fileContent struct {
+	Teams []team `json:"teams" yaml:"teams"`
+}
+
+// File reads a static list of teams from a YAML or JSON file.
+type File struct {
+	path   string
+	filter *teamfilter.Filter
+	log    logrus.FieldLogger
+}
+
+var _ TeamSource = (*File)(nil)
+
+func NewFile(path string, filter *teamfilter.Filter, log logrus.FieldLogger) *File {
+	return &File{
+		path:   path,
+		filter: filter,
+		log:    log,
+	}
+}
+
+func (s *File) Teams(_ context.Context) ([]naisapi.Team, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("read team file: %w", err)
+	}
+
+	content := &fileContent{}
+	if err := yaml.Unmarshal(data, content); err != nil {
+		return nil, fmt.Errorf("parse team file %q: %w", s.path, err)
+	}
+
+	return filterAndSort(toNaisTeams(content.Teams), s.filter, s.log), nil
+}
@@ -0,0 +1,54 @@
+package teamsource
+
+import (
+	"context"
+	"iter"
+
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/nais/slack-teams-notification/internal/teamfilter"
+	"github.com/sirupsen/logrus"
+)
+
+// NaisAPI fetches the teams from the live Nais API.
+type NaisAPI struct {
+	client *naisapi.Client
+	filter *teamfilter.Filter
+	log    logrus.FieldLogger
+}
+
+var (
+	_ TeamSource = (*NaisAPI)(nil)
+	_ Streamer   = (*NaisAPI)(nil)
+	_ Checker    = (*NaisAPI)(nil)
+)
+
+func NewNaisAPI(client *naisapi.Client, filter *teamfilter.Filter, log logrus.FieldLogger) *NaisAPI {
+	return &NaisAPI{
+		client: client,
+		filter: filter,
+		log:    log,
+	}
+}
+
+func (s *NaisAPI) Teams(ctx context.Context) ([]naisapi.Team, error) {
+	return naisapi.Collect(naisapi.SortedBySlug(s.StreamTeams(ctx)))
+}
+
+func (s *NaisAPI) StreamTeams(ctx context.Context) iter.Seq2[naisapi.Team, error] {
+	return s.filter.Apply(s.client.Teams(ctx), s.log)
+}
+
+// Check makes a preflight request to the Nais API, so a wrong endpoint or an expired token is reported before the
+// teams are fetched.
+func (s *NaisAPI) Check(ctx context.Context) error {
+	identity, err := s.client.Preflight(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.log.
+		WithField("identity_kind", identity.Kind).
+		WithField("identity_name", identity.Name).
+		Infof("authenticated with Nais API")
+	return nil
+}
@@ -0,0 +1,88 @@
+package teamsource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nais/slack-teams-notification/internal/fileutils"
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/nais/slack-teams-notification/internal/teamfilter"
+	"github.com/sirupsen/logrus"
+)
+
+// SnapshotVersion is the version of the snapshot format. It must be bumped when the format changes in a way that is
+// not backwards compatible.
+const SnapshotVersion = 1
+
+// snapshotContent is the format of a snapshot of the teams fetched by an earlier run.
+type snapshotContent struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	Teams     []team    `json:"teams"`
+}
+
+// Snapshot reads the teams from a snapshot saved by an earlier run.
+type Snapshot struct {
+	path   string
+	filter *teamfilter.Filter
+	log    logrus.FieldLogger
+}
+
+var _ TeamSource = (*Snapshot)(nil)
+
+func NewSnapshot(path string, filter *teamfilter.Filter, log logrus.FieldLogger) *Snapshot {
+	return &Snapshot{
+		path:   path,
+		filter: filter,
+		log:    log,
+	}
+}
+
+func (s *Snapshot) Teams(_ context.Context) ([]naisapi.Team, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("open snapshot: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	content := &snapshotContent{}
+	if err := json.NewDecoder(f).Decode(content); err != nil {
+		return nil, fmt.Errorf("decode snapshot %q: %w", s.path, err)
+	}
+
+	if content.Version != SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d in %q, expected %d", content.Version, s.path, SnapshotVersion)
+	}
+
+	return filterAndSort(toNaisTeams(content.Teams), s.filter, s.log), nil
+}
+
+// WriteSnapshot writes the teams to a snapshot that can be replayed by a later run, and returns the path of the written
+// file. If path is an existing directory, the snapshot is written to a file in that directory named after the time of
+// the snapshot.
+func WriteSnapshot(path string, teams []naisapi.Team, createdAt time.Time) (string, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, "snapshot-"+createdAt.UTC().Format("20060102T150405Z")+".json")
+	}
+
+	content := snapshotContent{
+		Version:   SnapshotVersion,
+		CreatedAt: createdAt.UTC(),
+		Teams:     fromNaisTeams(teams),
+	}
+
+	data, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encode snapshot: %w", err)
+	}
+
+	if err := fileutils.WriteAtomic(path, data); err != nil {
+		return "", fmt.Errorf("write snapshot: %w", err)
+	}
+
+	return path, nil
+}
@@ -0,0 +1,149 @@
+package teamsource
+
+import (
+	"context"
+	"iter"
+
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/nais/slack-teams-notification/internal/teamfilter"
+	"github.com/sirupsen/logrus"
+)
+
+// TeamSource provides the teams that should be notified.
+type TeamSource interface {
+	// Teams returns the teams of the source, sorted by slug.
+	Teams(ctx context.Context) ([]naisapi.Team, error)
+}
+
+// Streamer is implemented by team sources that can yield teams while they are still being fetched.
+type Streamer interface {
+	// StreamTeams returns an iterator over the teams of the source. The teams are not necessarily sorted.
+	StreamTeams(ctx context.Context) iter.Seq2[naisapi.Team, error]
+}
+
+// Checker is implemented by team sources that can check that they are usable before any teams are fetched.
+type Checker interface {
+	// Check returns an error if the source cannot provide the teams.
+	Check(ctx context.Context) error
+}
+
+// Check checks the source if it implements Checker.
+func Check(ctx context.Context, source TeamSource) error {
+	if checker, ok := source.(Checker); ok {
+		return checker.Check(ctx)
+	}
+	return nil
+}
+
+// Stream returns an iterator over the teams of the source. If the source implements Streamer the teams are yielded
+// as they arrive, otherwise they are yielded once the source has returned all of them.
+func Stream(ctx context.Context, source TeamSource) iter.Seq2[naisapi.Team, error] {
+	if streamer, ok := source.(Streamer); ok {
+		return streamer.StreamTeams(ctx)
+	}
+
+	return func(yield func(naisapi.Team, error) bool) {
+		teams, err := source.Teams(ctx)
+		if err != nil {
+			yield(naisapi.Team{}, err)
+			return
+		}
+
+		for team, err := range naisapi.Values(teams) {
+			if !yield(team, err) {
+				return
+			}
+		}
+	}
+}
+
+// team is the representation of a team in files read by the file and snapshot sources.
+type team struct {
+	Slug               string            `json:"slug" yaml:"slug"`
+	SlackChannel       string            `json:"slackChannel" yaml:"slackChannel"`
+	Purpose            string            `json:"purpose,omitempty" yaml:"purpose"`
+	DeletionInProgress bool              `json:"deletionInProgress,omitempty" yaml:"deletionInProgress"`
+	Environments       []environment     `json:"environments,omitempty" yaml:"environments"`
+	ExternalResources  externalResources `json:"externalResources" yaml:"externalResources"`
+	Members            []member          `json:"members" yaml:"members"`
+}
+
+type environment struct {
+	Name         string `json:"name" yaml:"name"`
+	GCPProjectID string `json:"gcpProjectID,omitempty" yaml:"gcpProjectID"`
+}
+
+type externalResources struct {
+	GitHubTeam   string `json:"gitHubTeam,omitempty" yaml:"gitHubTeam"`
+	GoogleGroup  string `json:"googleGroup,omitempty" yaml:"googleGroup"`
+	EntraIDGroup string `json:"entraIDGroup,omitempty" yaml:"entraIDGroup"`
+}
+
+type member struct {
+	Name  string `json:"name" yaml:"name"`
+	Email string `json:"email" yaml:"email"`
+	Role  string `json:"role" yaml:"role"`
+}
+
+func toNaisTeams(teams []team) []naisapi.Team {
+	naisTeams := make([]naisapi.Team, 0, len(teams))
+	for _, t := range teams {
+		members := make([]naisapi.Member, 0, len(t.Members))
+		for _, m := range t.Members {
+			members = append(members, naisapi.Member{
+				Name:  m.Name,
+				Email: m.Email,
+				Role:  m.Role,
+			})
+		}
+		var environments []naisapi.Environment
+		for _, env := range t.Environments {
+			environments = append(environments, naisapi.Environment(env))
+		}
+		naisTeams = append(naisTeams, naisapi.Team{
+			Slug:               t.Slug,
+			SlackChannel:       t.SlackChannel,
+			Purpose:            t.Purpose,
+			DeletionInProgress: t.DeletionInProgress,
+			Environments:       environments,
+			ExternalResources:  naisapi.ExternalResources(t.ExternalResources),
+			Members:            members,
+		})
+	}
+	return naisTeams
+}
+
+func fromNaisTeams(naisTeams []naisapi.Team) []team {
+	teams := make([]team, 0, len(naisTeams))
+	for _, t := range naisTeams {
+		members := make([]member, 0, len(t.Members))
+		for _, m := range t.Members {
+			members = append(members, member{
+				Name:  m.Name,
+				Email: m.Email,
+				Role:  m.Role,
+			})
+		}
+		var environments []environment
+		for _, env := range t.Environments {
+			environments = append(environments, environment(env))
+		}
+		teams = append(teams, team{
+			Slug:               t.Slug,
+			SlackChannel:       t.SlackChannel,
+			Purpose:            t.Purpose,
+			DeletionInProgress: t.DeletionInProgress,
+			Environments:       environments,
+			ExternalResources:  externalResources(t.ExternalResources),
+			Members:            members,
+		})
+	}
+	return teams
+}
+
+// filterAndSort keeps the teams that pass the filter, sorted by slug.
+func filterAndSort(teams []naisapi.Team, filter *teamfilter.Filter, log logrus.FieldLogger) []naisapi.Team {
+	// The teams are already in memory, so neither of the adapters can yield an error
+	filteredTeams, _ := naisapi.Collect(naisapi.SortedBySlug(filter.Apply(naisapi.Values(teams), log)))
+	return filteredTeams
+}
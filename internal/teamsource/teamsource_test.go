@@ -0,0 +1,157 @@
+package teamsource_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nais/slack-teams-notification/internal/naisapi"
+	"github.com/nais/slack-teams-notification/internal/teamfilter"
+	"github.com/nais/slack-teams-notification/internal/teamsource"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestFile(t *testing.T) {
+	ctx := context.Background()
+	log, _ := logrustest.NewNullLogger()
+
+	t.Run("yaml file", func(t *testing.T) {
+		path := writeFile(t, "teams.yaml", `
+teams:
+  - slug: team2
+    slackChannel: "#team2"
+    members:
+      - name: User Name
+        email: user.name@example.com
+        role: OWNER
+  - slug: team1
+    slackChannel: "#team1"
+`)
+
+		teams, err := teamsource.NewFile(path, nil, log).Teams(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if len(teams) != 2 {
+			t.Fatalf("expected 2 teams, got: %v", teams)
+		}
+
+		if teams[0].Slug != "team1" || teams[1].Slug != "team2" {
+			t.Errorf("expected teams to be sorted by slug, got: %v", teams)
+		}
+
+		if teams[1].SlackChannel != "#team2" {
+			t.Errorf("unexpected Slack channel: %q", teams[1].SlackChannel)
+		}
+
+		if len(teams[1].Members) != 1 || !teams[1].Members[0].IsOwner() {
+			t.Errorf("expected a single owner, got: %v", teams[1].Members)
+		}
+	})
+
+	t.Run("json file with filter", func(t *testing.T) {
+		path := writeFile(t, "teams.json", `{"teams": [{"slug": "team1"}, {"slug": "team2"}, {"slug": "team3"}]}`)
+		filter, err := teamfilter.New([]string{"team3", "team1"}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		teams, err := teamsource.NewFile(path, filter, log).Teams(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if len(teams) != 2 {
+			t.Fatalf("expected 2 teams, got: %v", teams)
+		}
+
+		if teams[0].Slug != "team1" || teams[1].Slug != "team3" {
+			t.Errorf("unexpected teams: %v", teams)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := teamsource.NewFile(filepath.Join(t.TempDir(), "missing.yaml"), nil, log).Teams(ctx); err == nil {
+			t.Fatalf("expected error, got nil")
+		}
+	})
+}
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+	log, _ := logrustest.NewNullLogger()
+
+	t.Run("read snapshot", func(t *testing.T) {
+		path := writeFile(t, "snapshot.json", `{
+			"version": 1,
+			"createdAt": "2025-01-11T10:00:00Z",
+			"teams": [{"slug": "team1", "members": [{"name": "User Name", "email": "user.name@example.com", "role": "MEMBER"}]}]
+		}`)
+
+		teams, err := teamsource.NewSnapshot(path, nil, log).Teams(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if len(teams) != 1 {
+			t.Fatalf("expected 1 team, got: %v", teams)
+		} else if len(teams[0].Members) != 1 {
+			t.Errorf("expected 1 member, got: %v", teams[0].Members)
+		}
+	})
+
+	t.Run("write and replay snapshot", func(t *testing.T) {
+		dir := t.TempDir()
+		createdAt := time.Date(2025, 1, 11, 10, 0, 0, 0, time.UTC)
+		teams := []naisapi.Team{
+			{
+				Slug:         "team1",
+				SlackChannel: "#team1",
+				Purpose:      "Some purpose",
+				Environments: []naisapi.Environment{{Name: "dev", GCPProjectID: "team1-dev-abcd"}},
+				ExternalResources: naisapi.ExternalResources{
+					GitHubTeam:  "team1",
+					GoogleGroup: "team1@example.com",
+				},
+				Members: []naisapi.Member{
+					{Name: "User Name", Email: "user.name@example.com", Role: "OWNER"},
+					{Name: "Other User Name", Email: "other.user.name@example.com", Role: "MEMBER"},
+				},
+			},
+			{Slug: "team2", SlackChannel: "#team2", DeletionInProgress: true, Members: []naisapi.Member{}},
+		}
+
+		path, err := teamsource.WriteSnapshot(dir, teams, createdAt)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if expected := filepath.Join(dir, "snapshot-20250111T100000Z.json"); path != expected {
+			t.Errorf("expected snapshot to be written to %q, got: %q", expected, path)
+		}
+
+		replayed, err := teamsource.NewSnapshot(path, nil, log).Teams(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if !reflect.DeepEqual(teams, replayed) {
+			t.Errorf("replayed teams differ from written teams:\nwritten:  %+v\nreplayed: %+v", teams, replayed)
+		}
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		path := writeFile(t, "snapshot.json", `{"version": 999, "teams": []}`)
+
+		_, err := teamsource.NewSnapshot(path, nil, log).Teams(ctx)
+		if err == nil {
+			t.Fatalf("expected error, got nil")
+		} else if !strings.Contains(err.Error(), "unsupported snapshot version 999") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("unable to write file: %v", err)
+	}
+	return path
+}